@@ -3,17 +3,21 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 	"github.com/smakimka/balb/internal/bot/bot"
+	"github.com/smakimka/balb/internal/bot/messenger"
 	"github.com/smakimka/balb/internal/bot/notifier"
 	"github.com/smakimka/balb/internal/bot/router"
+	"github.com/smakimka/balb/internal/bot/sender"
 	"github.com/smakimka/balb/internal/bot/storage"
 )
 
@@ -53,25 +57,232 @@ func main() {
 		return
 	}
 
-	api, err := tgbotapi.NewBotAPI(botToken)
+	requestRemindAfter := 12 * time.Hour
+	if durationStr := os.Getenv("ADMIN_REMIND_AFTER"); durationStr != "" {
+		requestRemindAfter, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing admin remind after")
+			return
+		}
+	}
+
+	codeTTL := 72 * time.Hour
+	if durationStr := os.Getenv("CODE_TTL"); durationStr != "" {
+		codeTTL, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing code ttl")
+			return
+		}
+	}
+
+	// Владелец из конфига, остальных админов он добавляет командой /admin.
+	// ADMIN_CHAT_IDS начальный список админов: при запуске добавляются те, кого ещё нет, остальных не трогаем
+	if err = s.SetOwner(ctx, fmt.Sprint(adminChatID)); err != nil {
+		log.Err(err).Msg("error setting owner")
+		return
+	}
+	if adminsStr := os.Getenv("ADMIN_CHAT_IDS"); adminsStr != "" {
+		for _, adminStr := range strings.Split(adminsStr, ",") {
+			admin, err := strconv.ParseInt(strings.TrimSpace(adminStr), 10, 64)
+			if err != nil {
+				log.Err(err).Msg("error converting admin chat ids")
+				return
+			}
+			if admin == int64(adminChatID) {
+				continue
+			}
+			if err = s.AddAdmin(ctx, fmt.Sprint(admin)); err != nil && !errors.Is(err, storage.ErrAdminAlreadyExists) {
+				log.Err(err).Msg("error adding admin")
+				return
+			}
+		}
+	}
+
+	inviteTTL := 7 * 24 * time.Hour
+	if durationStr := os.Getenv("INVITE_TTL"); durationStr != "" {
+		inviteTTL, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing invite ttl")
+			return
+		}
+	}
+
+	dialogTimeout := 30 * time.Minute
+	if timeoutStr := os.Getenv("DIALOG_TIMEOUT"); timeoutStr != "" {
+		dialogTimeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing dialog timeout")
+			return
+		}
+	}
+
+	// TELEGRAM_API_URL позволяет подключить бота к локальному эмулятору (cmd/tgemu) вместо Telegram
+	apiEndpoint := tgbotapi.APIEndpoint
+	if apiURL := os.Getenv("TELEGRAM_API_URL"); apiURL != "" {
+		apiEndpoint = strings.TrimSuffix(apiURL, "/") + "/bot%s/%s"
+	}
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(botToken, apiEndpoint)
 	if err != nil {
 		log.Err(err).Msg("error creating api")
 		return
 	}
 
-	notifier := notifier.New(s, api, adminChatID)
-	go notifier.Run(ctx)
+	sendRate := 30
+	if rateStr := os.Getenv("SEND_RATE"); rateStr != "" {
+		sendRate, err = strconv.Atoi(rateStr)
+		if err != nil {
+			log.Err(err).Msg("error converting send rate")
+			return
+		}
+	}
+
+	outbox := sender.New(api, sender.Config{
+		GlobalRate:    sendRate,
+		ChatInterval:  time.Second,
+		GroupInterval: 3 * time.Second,
+		MaxRetries:    3,
+	})
+	go outbox.Run(ctx)
+
+	collectionRemindDays := 3
+	if daysStr := os.Getenv("COLLECTION_REMIND_DAYS"); daysStr != "" {
+		collectionRemindDays, err = strconv.Atoi(daysStr)
+		if err != nil {
+			log.Err(err).Msg("error converting collection remind days")
+			return
+		}
+	}
+
+	pollDuration := 48 * time.Hour
+	if durationStr := os.Getenv("POLL_DURATION"); durationStr != "" {
+		pollDuration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing poll duration")
+			return
+		}
+	}
+
+	tokenAttempts := 3
+	if attemptsStr := os.Getenv("TOKEN_ATTEMPTS"); attemptsStr != "" {
+		tokenAttempts, err = strconv.Atoi(attemptsStr)
+		if err != nil {
+			log.Err(err).Msg("error converting token attempts")
+			return
+		}
+	}
+
+	tokenLockout := time.Minute
+	if durationStr := os.Getenv("TOKEN_LOCKOUT"); durationStr != "" {
+		tokenLockout, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing token lockout")
+			return
+		}
+	}
+
+	tokenGlobalLimit := 20
+	if limitStr := os.Getenv("TOKEN_GLOBAL_LIMIT"); limitStr != "" {
+		tokenGlobalLimit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			log.Err(err).Msg("error converting token global limit")
+			return
+		}
+	}
+
+	workers := 8
+	if workersStr := os.Getenv("UPDATE_WORKERS"); workersStr != "" {
+		workers, err = strconv.Atoi(workersStr)
+		if err != nil {
+			log.Err(err).Msg("error converting update workers")
+			return
+		}
+	}
+
+	queueSize := 100
+	if sizeStr := os.Getenv("UPDATE_QUEUE_SIZE"); sizeStr != "" {
+		queueSize, err = strconv.Atoi(sizeStr)
+		if err != nil {
+			log.Err(err).Msg("error converting update queue size")
+			return
+		}
+	}
 
 	bot := bot.New(
-		api,
-		"test",
+		messenger.New(api, outbox.Queue(sender.PriorityHigh)),
+		authToken,
 		http.Client{},
 		s,
-		adminChatID,
+		bot.Config{
+			AdminChatID:      adminChatID,
+			CodeTTL:          codeTTL,
+			InviteTTL:        inviteTTL,
+			DialogTimeout:    dialogTimeout,
+			PollDuration:     pollDuration,
+			AutoPoll:         os.Getenv("AUTO_POLL") == "true",
+			ElectOrganizer:   os.Getenv("ELECT_ORGANIZER") == "true",
+			Approval:         os.Getenv("REGISTRATION_APPROVAL") == "true",
+			TokenAttempts:    tokenAttempts,
+			TokenLockout:     tokenLockout,
+			TokenGlobalLimit: tokenGlobalLimit,
+			Workers:          workers,
+			QueueSize:        queueSize,
+		},
 	)
 
 	go bot.StartPolling(ctx)
 
+	var forumChatID int64
+	if forumStr := os.Getenv("FORUM_CHAT_ID"); forumStr != "" {
+		forumChatID, err = strconv.ParseInt(forumStr, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error converting forum chat id")
+			return
+		}
+	}
+
+	poolLowThreshold := 2
+	if thresholdStr := os.Getenv("POOL_LOW_THRESHOLD"); thresholdStr != "" {
+		poolLowThreshold, err = strconv.Atoi(thresholdStr)
+		if err != nil {
+			log.Err(err).Msg("error converting pool low threshold")
+			return
+		}
+	}
+
+	archiveAfterDays := 3
+	if daysStr := os.Getenv("ARCHIVE_AFTER_DAYS"); daysStr != "" {
+		archiveAfterDays, err = strconv.Atoi(daysStr)
+		if err != nil {
+			log.Err(err).Msg("error converting archive after days")
+			return
+		}
+	}
+
+	joinRemindAfter := 24 * time.Hour
+	if durationStr := os.Getenv("JOIN_REMIND_AFTER"); durationStr != "" {
+		joinRemindAfter, err = time.ParseDuration(durationStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing join remind after")
+			return
+		}
+	}
+
+	notifier := notifier.New(s, messenger.New(api, outbox.Queue(sender.PriorityLow)), bot, notifier.Config{
+		AdminChatID:          adminChatID,
+		CollectionRemindDays: collectionRemindDays,
+		ForumChatID:          forumChatID,
+		PoolChatPhoto:        os.Getenv("POOL_CHAT_PHOTO"),
+		PoolLowThreshold:     poolLowThreshold,
+		ArchiveAfterDays:     archiveAfterDays,
+		FarewellMessage:      os.Getenv("FAREWELL_MESSAGE"),
+		JoinRequests:         os.Getenv("JOIN_REQUESTS") == "true",
+		JoinRemindAfter:      joinRemindAfter,
+		RequestRemindAfter:   requestRemindAfter,
+		CodeTTL:              codeTTL,
+	})
+	go notifier.Run(ctx)
+
 	log.Info().Msg("listening on :8090")
 	if err := http.ListenAndServe(":8090", router.New(s)); err != nil {
 		log.Err(err).Msg("error")
@@ -43,7 +43,16 @@ func main() {
 		log.Err(err).Msg("error convertin days to int")
 	}
 
-	notifier := notifier.New(http.Client{}, s, daysInt)
+	wishlistInterval := time.Hour
+	if intervalStr := os.Getenv("WISHLIST_NOTIFICATION_INTERVAL"); intervalStr != "" {
+		wishlistInterval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			log.Err(err).Msg("error parsing wishlist notification interval")
+			return
+		}
+	}
+
+	notifier := notifier.New(http.Client{}, s, daysInt, wishlistInterval)
 	go notifier.Run(ctx)
 
 	log.Info().Msg("listening on :8090")
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/smakimka/balb/internal/tgemu"
+)
+
+func main() {
+	addr := os.Getenv("TGEMU_ADDR")
+	if addr == "" {
+		addr = ":8081"
+	}
+
+	token := os.Getenv("BOT_TOKEN")
+	if token == "" {
+		log.Error().Msg("bot token is empty")
+		return
+	}
+
+	username := os.Getenv("BOT_USERNAME")
+	if username == "" {
+		username = "balb_emu_bot"
+	}
+
+	log.Info().Msgf("listening on %s", addr)
+	if err := http.ListenAndServe(addr, tgemu.New(token, username).Handler()); err != nil {
+		log.Err(err).Msg("error")
+	}
+}
@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+var adminRoleNames = map[string]string{
+	storage.AdminRoleOwner: "владелец",
+	storage.AdminRoleAdmin: "админ",
+}
+
+func (b *Bot) isAdmin(ctx context.Context, userID int64) bool {
+	_, err := b.s.GetAdmin(ctx, fmt.Sprint(userID))
+	if err != nil && !errors.Is(err, storage.ErrAdminNotFound) {
+		log.Err(err).Msg("error getting admin")
+	}
+
+	return err == nil
+}
+
+func (b *Bot) isOwner(ctx context.Context, userID int64) bool {
+	admin, err := b.s.GetAdmin(ctx, fmt.Sprint(userID))
+	if err != nil && !errors.Is(err, storage.ErrAdminNotFound) {
+		log.Err(err).Msg("error getting admin")
+	}
+
+	return err == nil && admin.Role == storage.AdminRoleOwner
+}
+
+// adminChatIDs все админы, если список недоступен - только AdminChatID
+func (b *Bot) adminChatIDs(ctx context.Context) []int64 {
+	res := []int64{}
+
+	admins, err := b.s.GetAdmins(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting admins")
+	}
+	for _, admin := range admins {
+		chatID, err := strconv.ParseInt(admin.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+		res = append(res, chatID)
+	}
+
+	if len(res) == 0 {
+		return []int64{int64(b.cfg.AdminChatID)}
+	}
+
+	return res
+}
+
+// notifyAdmins отправляет всем админам сообщение о том, что требует их внимания
+func (b *Bot) notifyAdmins(ctx context.Context, text string) {
+	for _, adminChatID := range b.adminChatIDs(ctx) {
+		if _, err := b.a.Send(messenger.Text(adminChatID, text)); err != nil {
+			log.Err(err).Msg("error notifying admin")
+		}
+	}
+}
+
+// admin управляет списком админов, доступно только владельцу: /admin add|remove <chat-id>, /admin list
+func (b *Bot) admin(ctx context.Context, message *messenger.Message) {
+	if !b.isOwner(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только владельцу")
+		b.a.Send(msg)
+		return
+	}
+
+	action, arg, _ := strings.Cut(strings.TrimSpace(message.CommandArguments()), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch action {
+	case "list":
+		b.adminList(ctx, message)
+		return
+	case "add", "remove":
+	default:
+		msg := messenger.Text(message.From.ID, "Использование: /admin add <chat-id>, /admin remove <chat-id> или /admin list, chat id можно посмотреть в /list")
+		b.a.Send(msg)
+		return
+	}
+
+	if _, err := strconv.ParseInt(arg, 10, 64); err != nil {
+		msg := messenger.Text(message.From.ID, "chat id должен быть числом, его можно посмотреть в /list")
+		b.a.Send(msg)
+		return
+	}
+
+	var err error
+	text := fmt.Sprintf("%s теперь админ", arg)
+	if action == "add" {
+		err = b.s.AddAdmin(ctx, arg)
+	} else {
+		err = b.s.RemoveAdmin(ctx, arg)
+		text = fmt.Sprintf("%s больше не админ", arg)
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrAdminAlreadyExists):
+		text = "Он уже админ"
+	case errors.Is(err, storage.ErrAdminNotFound):
+		text = "Такого админа нет, владельца убрать нельзя"
+	default:
+		log.Err(err).Msg("error changing admins")
+		text = "Ошибка, попробуйте позже"
+	}
+
+	msg := messenger.Text(message.From.ID, text)
+	b.a.Send(msg)
+}
+
+func (b *Bot) adminList(ctx context.Context, message *messenger.Message) {
+	admins, err := b.s.GetAdmins(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting admins")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	lines := []string{"Админы:"}
+	for _, admin := range admins {
+		chatID, err := strconv.ParseInt(admin.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("- %s (%s), %s", b.mention(chatID), admin.ChatID, adminRoleNames[admin.Role]))
+	}
+
+	msg := messenger.HTML(message.From.ID, strings.Join(lines, "\n"))
+	b.a.Send(msg)
+}
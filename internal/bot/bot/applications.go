@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/dialog"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// submitApplication сохраняет заполненную анкету и отправляет её всем админам на одобрение
+func (b *Bot) submitApplication(ctx context.Context, chatID int64, application dialog.UserData) {
+	data := storage.ApplicationData{
+		ChatID:   fmt.Sprint(chatID),
+		FIO:      application.FIO,
+		Birthday: application.Birthday,
+		Wishlist: application.Wishlist,
+	}
+	if err := b.s.SaveApplication(ctx, data); err != nil {
+		text := "Ошибка, заявка не отправлена, попробуйте позже командой /start"
+		if errors.Is(err, storage.ErrApplicationDecided) {
+			text = "Ваша заявка на регистрацию уже рассмотрена, если это ошибка - свяжитесь с админом"
+		} else {
+			log.Err(err).Msg("error saving application")
+		}
+
+		b.d.Reject(chatID)
+		b.a.Send(messenger.Text(chatID, text))
+		return
+	}
+
+	for _, adminChatID := range b.adminChatIDs(ctx) {
+		msg := messenger.HTML(adminChatID, "Новая заявка на регистрацию\n\n"+applicationText(data))
+		msg.Keyboard = applicationKeyboard(data.ChatID)
+		if _, err := b.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending application to admin")
+		}
+	}
+}
+
+func applicationText(application storage.ApplicationData) string {
+	return fmt.Sprintf(
+		"<a href=\"tg://user?id=%s\">%s</a> (%s)\nДата рождения: %s\nВишлист:\n%s",
+		application.ChatID, html.EscapeString(application.FIO), application.ChatID, application.Birthday.Format("02.01.2006"), html.EscapeString(application.Wishlist),
+	)
+}
+
+func applicationKeyboard(chatID string) messenger.Keyboard {
+	return messenger.Keyboard{{
+		{Text: "Одобрить", Data: "appl:yes:" + chatID},
+		{Text: "Отклонить", Data: "appl:no:" + chatID},
+	}}
+}
+
+// handleApplicationCallback решение админа по заявке, кнопки приходят в виде appl:yes|no:<chat-id>
+func (b *Bot) handleApplicationCallback(ctx context.Context, query *messenger.Callback) {
+	if !b.isAdmin(ctx, query.From.ID) {
+		b.answerCallback(query, "Решать по заявкам может только админ")
+		return
+	}
+
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 {
+		b.answerCallback(query, "")
+		return
+	}
+	chatID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		b.answerCallback(query, "")
+		return
+	}
+
+	application, err := b.s.GetApplication(ctx, parts[2])
+	if err != nil || application.Status != storage.ApplicationPending {
+		if err != nil && !errors.Is(err, storage.ErrApplicationNotFound) {
+			log.Err(err).Msg("error getting application")
+			b.answerCallback(query, "Ошибка, попробуйте позже")
+			return
+		}
+		b.answerCallback(query, "Эту заявку уже рассмотрели")
+		return
+	}
+
+	status, verdict, text := storage.ApplicationApproved, "Одобрено", "Ваша заявка одобрена, добро пожаловать! Список команд можно посмотреть в меню"
+	if parts[1] != "yes" {
+		status, verdict, text = storage.ApplicationRejected, "Отклонено", "Ваша заявка на регистрацию отклонена, если это ошибка - свяжитесь с админом"
+	}
+
+	// Сначала фиксируем решение, чтобы два админа не рассмотрели заявку одновременно по-разному
+	if err = b.s.DecideApplication(ctx, parts[2], status); err != nil {
+		if !errors.Is(err, storage.ErrApplicationNotFound) {
+			log.Err(err).Msg("error deciding application")
+			b.answerCallback(query, "Ошибка, попробуйте позже")
+			return
+		}
+		b.answerCallback(query, "Эту заявку уже рассмотрели")
+		return
+	}
+
+	if status == storage.ApplicationRejected {
+		b.d.Reject(chatID)
+	} else {
+		userData := dialog.UserData{FIO: application.FIO, Birthday: application.Birthday, Wishlist: application.Wishlist}
+		if err = b.d.Approve(chatID, userData); err != nil {
+			log.Err(err).Msg("error adding approved user")
+			// Возвращаем заявку на рассмотрение, чтобы её можно было одобрить ещё раз
+			if err = b.s.ReopenApplication(ctx, parts[2]); err != nil {
+				log.Err(err).Msg("error restoring application")
+			}
+			b.answerCallback(query, "Не получилось зарегистрировать, попробуйте позже")
+			return
+		}
+	}
+	b.answerCallback(query, verdict)
+
+	if query.Message != nil {
+		edit := messenger.Edit(query.Message.Chat.ID, query.Message.MessageID,
+			fmt.Sprintf("%s\n\n%s: %s", applicationText(application), verdict, b.mention(query.From.ID)))
+		edit.HTML = true
+		if err = b.a.Edit(edit); err != nil {
+			log.Err(err).Msg("error editing application message")
+		}
+	}
+
+	if _, err = b.a.Send(messenger.Text(chatID, text)); err != nil {
+		log.Err(err).Msg("error sending application verdict")
+	}
+}
+
+// applicationStatus что ответить человеку, чья заявка ещё рассматривается или отклонена, false если ему можно продолжать
+func (b *Bot) applicationStatus(ctx context.Context, chatID int64) (string, bool) {
+	application, err := b.s.GetApplication(ctx, fmt.Sprint(chatID))
+	if err != nil {
+		if !errors.Is(err, storage.ErrApplicationNotFound) {
+			log.Err(err).Msg("error getting application")
+		}
+		return "", false
+	}
+
+	switch application.Status {
+	case storage.ApplicationPending:
+		return "Ваша заявка на регистрацию ещё на рассмотрении у админа, я напишу, когда её рассмотрят", true
+	case storage.ApplicationRejected:
+		return "Ваша заявка на регистрацию отклонена, если это ошибка - свяжитесь с админом", true
+	}
+
+	return "", false
+}
+
+// applications показывает админу заявки, ждущие решения, каждую со своими кнопками
+func (b *Bot) applications(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	applications, err := b.s.GetPendingApplications(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting pending applications")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	if len(applications) == 0 {
+		msg := messenger.Text(message.From.ID, "Заявок на рассмотрении нет")
+		b.a.Send(msg)
+		return
+	}
+
+	for _, application := range applications {
+		msg := messenger.HTML(message.From.ID, applicationText(application))
+		msg.Keyboard = applicationKeyboard(application.ChatID)
+		if _, err = b.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending pending application")
+		}
+	}
+}
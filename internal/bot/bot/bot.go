@@ -4,124 +4,379 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog/log"
 	"github.com/smakimka/balb/internal/bot/dialog"
+	"github.com/smakimka/balb/internal/bot/dispatcher"
+	"github.com/smakimka/balb/internal/bot/messenger"
 	"github.com/smakimka/balb/internal/bot/storage"
 	"github.com/smakimka/balb/internal/model"
 )
 
+type Config struct {
+	AdminChatID int
+	// CodeTTL сколько действует код привязки беседы, InviteTTL - ссылка-приглашение на регистрацию по умолчанию
+	CodeTTL       time.Duration
+	InviteTTL     time.Duration
+	DialogTimeout time.Duration
+	// PollDuration сколько длится опрос о подарке, AutoPoll - создавать ли его сразу при привязке беседы
+	PollDuration time.Duration
+	AutoPoll     bool
+	// ElectOrganizer выбирать ли случайного организатора при привязке беседы
+	ElectOrganizer bool
+	// Approval регистрация без токена, но каждую анкету одобряет админ
+	Approval bool
+	// TokenAttempts сколько раз подряд можно ошибиться в токене до блокировки на TokenLockout, дальше она удваивается,
+	// TokenGlobalLimit - сколько ошибок в минуту во всех чатах вместе приостанавливают ввод токена для всех (0 - без ограничения)
+	TokenAttempts    int
+	TokenLockout     time.Duration
+	TokenGlobalLimit int
+	// Workers сколько чатов обрабатываются параллельно, QueueSize - сколько апдейтов ждут в очереди каждого воркера
+	Workers   int
+	QueueSize int
+}
+
 type Bot struct {
-	a           *tgbotapi.BotAPI
-	c           http.Client
-	d           *dialog.Dialog
-	s           storage.Storage
-	adminChatID int
+	a   messenger.Messenger
+	c   http.Client
+	d   *dialog.Dialog
+	s   storage.Storage
+	cfg Config
 }
 
-func New(a *tgbotapi.BotAPI, startToken string, c http.Client, s storage.Storage, adminChatID int) *Bot {
-	d := dialog.New(startToken, c)
-	return &Bot{a: a, c: c, d: d, s: s, adminChatID: adminChatID}
+func New(a messenger.Messenger, startToken string, c http.Client, s storage.Storage, cfg Config) *Bot {
+	b := &Bot{a: a, c: c, s: s, cfg: cfg}
+	b.d = dialog.New(startToken, c, &tokenGuard{b: b}, cfg.Approval)
+	return b
 }
 
 func (b *Bot) StartPolling(ctx context.Context) {
-	u := tgbotapi.NewUpdate(0)
-	updates := b.a.GetUpdatesChan(u)
+	go b.expireDialogs(ctx)
+
+	updates := b.a.Updates(ctx)
+
+	// Апдейты одного чата обрабатываются по очереди, иначе диалог регистрации может получить сообщения не в том порядке
+	d := dispatcher.New(b.cfg.Workers, b.cfg.QueueSize)
+	d.Run()
+	defer d.Stop()
 
 	for update := range updates {
-		if update.Message == nil {
+		key, job := b.route(ctx, update)
+		if job == nil {
 			continue
 		}
 
-		if update.Message.IsCommand() {
-			if update.Message.Text == "/start" {
-				go b.handleCommand(ctx, update.Message)
-				continue
-			}
+		if !d.Dispatch(ctx, key, job) {
+			return
+		}
+	}
+}
 
-			if b.d.IsRegistered(update.Message.From.ID) {
-				go b.handleCommand(ctx, update.Message)
-				continue
-			}
+// route чат, к которому относится апдейт, и его обработчик, nil если обрабатывать нечего
+func (b *Bot) route(ctx context.Context, update messenger.Update) (int64, func()) {
+	if update.Callback != nil {
+		return update.Callback.From.ID, func() { b.handleCallback(ctx, update.Callback) }
+	}
+
+	if update.MyChatMember != nil {
+		return update.MyChatMember.Chat.ID, func() { b.handleMyChatMember(ctx, update.MyChatMember) }
+	}
+
+	if update.ChatMember != nil {
+		return update.ChatMember.Chat.ID, func() { b.handleChatMember(ctx, update.ChatMember) }
+	}
+
+	if update.JoinRequest != nil {
+		return update.JoinRequest.Chat.ID, func() { b.handleJoinRequest(ctx, update.JoinRequest) }
+	}
+
+	message := update.Message
+	if message == nil {
+		return 0, nil
+	}
+
+	if message.MigrateToChatID != 0 {
+		return message.Chat.ID, func() { b.migrateChat(ctx, message.Chat, message.MigrateToChatID) }
+	}
+
+	// Регистрация идёт только в личке, сообщения из бесед в диалог не попадают
+	if !message.IsCommand() && !message.Chat.IsPrivate() {
+		return 0, nil
+	}
+
+	return message.Chat.ID, func() { b.handleMessage(ctx, message) }
+}
+
+func (b *Bot) handleMessage(ctx context.Context, message *messenger.Message) {
+	if message.IsCommand() {
+		if message.Command() == "start" || b.d.IsRegistered(message.From.ID) {
+			b.handleCommand(ctx, message)
+			return
 		}
+	}
+
+	if !message.Chat.IsPrivate() {
+		return
+	}
+
+	if b.cfg.Approval {
+		if text, ok := b.applicationStatus(ctx, message.From.ID); ok {
+			b.a.Send(messenger.Text(message.From.ID, text))
+			return
+		}
+	}
+
+	msg := b.d.HandleMessage(ctx, message.From.ID, message.Text)
+	if msg != nil {
+		b.a.Send(*msg)
+	}
+}
+
+// expireDialogs сбрасывает брошенные на середине регистрации и напоминает о них
+func (b *Bot) expireDialogs(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 
-		go func() {
-			msg := b.d.HandleMessage(ctx, update.Message.From.ID, update.Message.Text)
-			if msg != nil {
-				b.a.Send(msg)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, chatID := range b.d.ExpireIdle(b.cfg.DialogTimeout) {
+				msg := messenger.Text(chatID, "Регистрация не была завершена и сброшена, чтобы начать заново отправьте /start")
+				if _, err := b.a.Send(msg); err != nil {
+					log.Err(err).Msg("error sending dialog reminder")
+				}
 			}
-		}()
+		}
+	}
+}
+
+func (b *Bot) handleCallback(ctx context.Context, query *messenger.Callback) {
+	if strings.HasPrefix(query.Data, "wish:") || strings.HasPrefix(query.Data, "gift:") {
+		b.handleWishlistCallback(ctx, query)
+		return
+	}
+
+	if strings.HasPrefix(query.Data, "appl:") {
+		b.handleApplicationCallback(ctx, query)
+		return
+	}
+
+	b.answerCallback(query, "")
+
+	if query.Message == nil {
+		return
+	}
+
+	// Отправляем анкету только при переходе в ожидание, повторные нажатия "Подтвердить" её не пересылают
+	_, wasPending := b.d.Application(query.From.ID)
+
+	for _, msg := range b.d.HandleCallback(ctx, query.From.ID, query.Message.MessageID, query.Data) {
+		if err := b.reply(msg); err != nil {
+			log.Err(err).Msg("error sending callback response")
+		}
+	}
+
+	if query.Data == "reg:confirm" && !wasPending {
+		if application, ok := b.d.Application(query.From.ID); ok {
+			b.submitApplication(ctx, query.From.ID, application)
+		}
+	}
+
+	if query.Data == "reg:confirm" {
+		b.useInviteCode(ctx, query.From.ID)
+	}
+}
+
+// reply отправляет ответ диалога: с MessageID редактирует это сообщение, без - отправляет новое
+func (b *Bot) reply(msg messenger.Outgoing) error {
+	if msg.MessageID != 0 {
+		return b.a.Edit(msg)
 	}
+
+	_, err := b.a.Send(msg)
+	return err
 }
 
-func (b *Bot) handleCommand(ctx context.Context, message *tgbotapi.Message) {
+func (b *Bot) handleCommand(ctx context.Context, message *messenger.Message) {
 	switch message.Command() {
 	case "start":
-		msg := tgbotapi.NewMessage(message.From.ID, "Для использования этого бота необходимо авторизоваться, введите токен")
-		b.d.Reset(message.From.ID)
-		b.a.Send(msg)
+		b.start(ctx, message)
+	case "invite":
+		b.invite(ctx, message)
+	case "invites":
+		b.invites(ctx, message)
+	case "revoke":
+		b.revoke(ctx, message)
 	case "list":
 		b.list(ctx, message)
+	case "profile":
+		b.a.Send(b.d.Profile(message.From.ID))
 	case "subscribe":
 		b.subscribe(ctx, message)
 	case "unsubscribe":
 		b.unsubscribe(ctx, message)
+	case "wishlist_updates":
+		b.wishlistUpdates(ctx, message)
+	case "wish_add":
+		b.wishAdd(ctx, message)
+	case "wishes":
+		b.wishes(ctx, message)
+	case "gifts":
+		b.gifts(ctx, message)
+	case "collect":
+		b.collect(ctx, message)
+	case "chipin":
+		b.chipin(ctx, message)
+	case "poll":
+		b.poll(ctx, message)
+	case "handoff":
+		b.handoff(ctx, message)
+	case "pool":
+		b.pool(ctx, message)
+	case "admin":
+		b.admin(ctx, message)
+	case "pending":
+		b.pending(ctx, message)
+	case "applications":
+		b.applications(ctx, message)
+	case "regen":
+		b.regen(ctx, message)
+	case "who":
+		b.who(ctx, message)
 	case "birthday":
 		b.birthday(ctx, message)
 	}
 }
 
-func (b *Bot) birthday(ctx context.Context, message *tgbotapi.Message) {
-	if message.From.ID != int64(b.adminChatID) {
-		msg := tgbotapi.NewMessage(message.From.ID, "Эту команду можно использовать только админу")
+func (b *Bot) birthday(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+	if !message.Chat.IsGroup() && !message.Chat.IsSuperGroup() {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только в групповых чатах")
+		b.a.Send(msg)
+		return
+	}
+
+	// Пустой код есть у всех дней рождения, для которых беседу ещё не запрашивали
+	code := strings.TrimSpace(message.CommandArguments())
+	if code == "" {
+		msg := messenger.Text(message.From.ID, "Не знаю такого кода, список ожидающих беседу: /pending")
+		b.a.Send(msg)
+		return
+	}
+
+	pending, err := b.s.GetBirthdayByCode(ctx, code)
+	if err != nil {
+		msg := messenger.Text(message.From.ID, "Не знаю такого кода, список ожидающих беседу: /pending")
+		b.a.Send(msg)
+		return
+	}
+	if pending.ChatID != "" {
+		msg := messenger.Text(message.From.ID, "Беседа по этому коду уже привязана")
 		b.a.Send(msg)
 		return
 	}
-	if !message.Chat.IsGroup() {
-		msg := tgbotapi.NewMessage(message.From.ID, "Эту команду можно использовать только в групповых чатах")
+	if pending.CodeExpired(time.Now().UTC()) {
+		msg := messenger.Text(message.From.ID, fmt.Sprintf("Код истёк, получите новый командой /regen %s", code))
 		b.a.Send(msg)
 		return
 	}
 
-	link, err := b.a.GetInviteLink(tgbotapi.ChatInviteLinkConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: message.Chat.ID}})
+	link, err := b.a.ChatLink(message.Chat.ID)
 	if err != nil {
-		msg := tgbotapi.NewMessage(message.From.ID, "Не получилось создать ссылку, я точно админ?, проверьте и попробуйте ещё раз")
+		msg := messenger.Text(message.From.ID, "Не получилось создать ссылку, я точно админ?, проверьте и попробуйте ещё раз")
 		b.a.Send(msg)
 		return
 	}
 
-	if err = b.s.UpdateLinkAndChatIDByCode(ctx, message.CommandArguments(), fmt.Sprint(message.Chat.ID), link); err != nil {
+	if err = b.s.UpdateLinkAndChatIDByCode(ctx, code, fmt.Sprint(message.Chat.ID), link); err != nil {
+		if errors.Is(err, storage.ErrBirthdayNotFound) {
+			msg := messenger.Text(message.From.ID, "Беседа по этому коду уже привязана")
+			b.a.Send(msg)
+			return
+		}
+
 		log.Err(err).Msg("error updating chat link")
-		msg := tgbotapi.NewMessage(message.From.ID, "ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(
+	msg := messenger.Text(
 		message.From.ID,
-		fmt.Sprintf("Ссылка в чате по коду %s успешно создана, рассылка приглашений скоро начнется", message.CommandArguments()),
+		fmt.Sprintf("Ссылка в чате по коду %s успешно создана, рассылка приглашений скоро начнется", code),
 	)
 	b.a.Send(msg)
 
-	birthday, err := b.s.GetBirthdayByCode(ctx, message.CommandArguments())
+	birthday, err := b.s.GetBirthdayByCode(ctx, code)
 	if err != nil {
 		log.Err(err).Msg("error getting data on group message")
 		return
 	}
 
-	msg = tgbotapi.NewMessage(
-		message.Chat.ID,
-		fmt.Sprintf("Это беседа дня рождения %s (%s) wishlist:\n%s", birthday.FIO, birthday.Date.Format("02.01"), birthday.Wishlist),
-	)
-	b.a.Send(msg)
+	b.Bind(ctx, message.Chat.ID, birthday)
+}
+
+// migrateChat переносит привязку беседы, когда Telegram превращает группу в супергруппу и меняет её chat id
+func (b *Bot) migrateChat(ctx context.Context, oldChat messenger.Chat, newChatID int64) {
+	link, err := b.a.ChatLink(newChatID)
+	if err != nil {
+		log.Err(err).Msg("error getting migrated chat invite link")
+		link = ""
+	}
+
+	if err = b.s.MigrateChat(ctx, fmt.Sprint(oldChat.ID), fmt.Sprint(newChatID), link); err != nil {
+		log.Err(err).Msg("error migrating chat")
+		return
+	}
+
+	log.Info().Int64("old", oldChat.ID).Int64("new", newChatID).Msg("chat migrated to supergroup")
+
+	// Старая ссылка вела в группу, которой больше нет, так что без новой приглашать некуда
+	if link == "" {
+		b.breakChat(ctx, messenger.Chat{ID: newChatID, Title: oldChat.Title}, "Не получилось создать ссылку после перехода в супергруппу")
+	}
+}
+
+// Bind публикует и закрепляет вишлист в только что привязанной беседе и запускает включённые в конфиге опрос и выбор организатора
+func (b *Bot) Bind(ctx context.Context, chatID int64, birthday storage.BirthdayData) {
+	messageID, err := b.a.Send(messenger.Text(chatID, birthday.WishlistText()))
+	if err != nil {
+		log.Err(err).Msg("error sending wishlist")
+		return
+	}
+
+	if err = b.a.Pin(chatID, messageID); err != nil {
+		log.Err(err).Msg("error pinning wishlist")
+	}
+
+	if err = b.s.SetWishlistMessageID(ctx, birthday.ID, messageID); err != nil {
+		log.Err(err).Msg("error saving wishlist message id")
+	}
+
+	if b.cfg.AutoPoll {
+		if err = b.startPoll(ctx, chatID, birthday, nil); err != nil {
+			log.Err(err).Msg("error starting poll")
+		}
+	}
+
+	if b.cfg.ElectOrganizer {
+		b.electOrganizer(ctx, chatID, birthday)
+	}
 }
 
-func (b *Bot) subscribe(_ context.Context, message *tgbotapi.Message) {
+func (b *Bot) subscribe(_ context.Context, message *messenger.Message) {
 	data := model.SubscriptionData{
 		Front:         model.TelegramFront,
 		SubscriberUID: fmt.Sprint(message.From.ID),
@@ -136,7 +391,7 @@ func (b *Bot) subscribe(_ context.Context, message *tgbotapi.Message) {
 	resp, err := b.c.Post(fmt.Sprintf("http://server:8090/subscriptions/subscribe"), "application/json", bytes.NewReader(body))
 	if err != nil {
 		log.Err(err).Msg("error sending subscribe request")
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -145,7 +400,7 @@ func (b *Bot) subscribe(_ context.Context, message *tgbotapi.Message) {
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
 		log.Err(err).Msg("error reading body")
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -154,27 +409,33 @@ func (b *Bot) subscribe(_ context.Context, message *tgbotapi.Message) {
 		var response model.Response
 		if err = json.Unmarshal(body, &response); err != nil {
 			log.Err(err).Msg("error unmarshalling response")
-			msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+			msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 			b.a.Send(msg)
 			return
 		}
 
 		if response.Msg == "subscription already exists" {
-			msg := tgbotapi.NewMessage(message.From.ID, "Вы уже подписаны")
+			msg := messenger.Text(message.From.ID, "Вы уже подписаны")
 			b.a.Send(msg)
 			return
 		}
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		if response.Msg == "self subscription" {
+			msg := messenger.Text(message.From.ID, "На себя подписаться нельзя, иначе какой же это сюрприз")
+			b.a.Send(msg)
+			return
+		}
+
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.From.ID, "Подписка оформлена")
+	msg := messenger.Text(message.From.ID, "Подписка оформлена")
 	b.a.Send(msg)
 }
 
-func (b *Bot) unsubscribe(_ context.Context, message *tgbotapi.Message) {
+func (b *Bot) unsubscribe(_ context.Context, message *messenger.Message) {
 	data := model.SubscriptionData{
 		Front:         model.TelegramFront,
 		SubscriberUID: fmt.Sprint(message.From.ID),
@@ -189,7 +450,7 @@ func (b *Bot) unsubscribe(_ context.Context, message *tgbotapi.Message) {
 	resp, err := b.c.Post(fmt.Sprintf("http://server:8090/subscriptions/unsubscribe"), "application/json", bytes.NewReader(body))
 	if err != nil {
 		log.Err(err).Msg("error sending subscribe request")
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -198,7 +459,7 @@ func (b *Bot) unsubscribe(_ context.Context, message *tgbotapi.Message) {
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
 		log.Err(err).Msg("error reading body")
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -207,32 +468,85 @@ func (b *Bot) unsubscribe(_ context.Context, message *tgbotapi.Message) {
 		var response model.Response
 		if err = json.Unmarshal(body, &response); err != nil {
 			log.Err(err).Msg("error unmarshalling response")
-			msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+			msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 			b.a.Send(msg)
 			return
 		}
 
 		if response.Msg == "subscription not found" {
-			msg := tgbotapi.NewMessage(message.From.ID, "Вы не были подписаны")
+			msg := messenger.Text(message.From.ID, "Вы не были подписаны")
 			b.a.Send(msg)
 			return
 		}
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(message.From.ID, "Подписка отменена")
+	b.a.Send(msg)
+}
+
+// wishlistUpdates включает или выключает уведомления об изменении вишлиста: /wishlist_updates <chat-id> on|off
+func (b *Bot) wishlistUpdates(_ context.Context, message *messenger.Message) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		msg := messenger.Text(message.From.ID, "Использование: /wishlist_updates <chat-id> on|off")
+		b.a.Send(msg)
+		return
+	}
+
+	data := model.WishlistUpdatesData{
+		SubscriptionData: model.SubscriptionData{
+			Front:         model.TelegramFront,
+			SubscriberUID: fmt.Sprint(message.From.ID),
+			UserUID:       args[0],
+		},
+		Enabled: args[1] == "on",
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		log.Err(err).Msg("error marshailling data")
+		return
+	}
+
+	resp, err := b.c.Post(fmt.Sprintf("http://server:8090/subscriptions/wishlist"), "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Err(err).Msg("error sending wishlist updates request")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		msg := messenger.Text(message.From.ID, "Вы не подписаны на этого пользователя")
 		b.a.Send(msg)
 		return
 	}
 
-	msg := tgbotapi.NewMessage(message.From.ID, "Подписка отменена")
+	if resp.StatusCode != http.StatusOK {
+		log.Error().Int("code", resp.StatusCode).Msg("error setting wishlist updates")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	text := "Уведомления об изменении вишлиста включены"
+	if !data.Enabled {
+		text = "Уведомления об изменении вишлиста выключены"
+	}
+	msg := messenger.Text(message.From.ID, text)
 	b.a.Send(msg)
 }
 
-func (b *Bot) list(_ context.Context, message *tgbotapi.Message) {
+func (b *Bot) list(_ context.Context, message *messenger.Message) {
 	resp, err := b.c.Get(fmt.Sprintf("http://server:8090/users/get/%d", model.TelegramFront))
 	if err != nil {
 		log.Err(err).Msg("error getting users")
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -241,7 +555,7 @@ func (b *Bot) list(_ context.Context, message *tgbotapi.Message) {
 	if resp.StatusCode != 200 {
 		log.Error().Int("code", resp.StatusCode).Msg("error getting users")
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 		return
 	}
@@ -250,7 +564,7 @@ func (b *Bot) list(_ context.Context, message *tgbotapi.Message) {
 	if err != nil {
 		log.Err(err).Msg("error unmarshalling users")
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 	}
 
@@ -259,7 +573,7 @@ func (b *Bot) list(_ context.Context, message *tgbotapi.Message) {
 	if err != nil {
 		log.Err(err).Msg("error unmarshalling users")
 
-		msg := tgbotapi.NewMessage(message.From.ID, "Ошибка, попробуйте позже")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
 		b.a.Send(msg)
 	}
 
@@ -268,6 +582,6 @@ func (b *Bot) list(_ context.Context, message *tgbotapi.Message) {
 		msgText = append(msgText, fmt.Sprintf("%d. %s - %s", i+1, user.FIO, user.UID))
 	}
 
-	msg := tgbotapi.NewMessage(message.From.ID, strings.Join(msgText, "\n"))
+	msg := messenger.Text(message.From.ID, strings.Join(msgText, "\n"))
 	b.a.Send(msg)
 }
@@ -0,0 +1,682 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+	mock_storage "github.com/smakimka/balb/internal/bot/storage/mock"
+	"github.com/smakimka/balb/internal/model"
+)
+
+const (
+	userID  int64 = 10
+	adminID int64 = 100
+	groupID int64 = -200
+)
+
+type response struct {
+	code int
+	body string
+}
+
+// fakeServer отвечает вместо сервера по пути запроса, на неизвестные пути - 404
+type fakeServer map[string]response
+
+func (f fakeServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, ok := f[r.URL.RequestURI()]
+	if !ok {
+		resp = response{code: http.StatusNotFound, body: `{"msg":"not found"}`}
+	}
+
+	return &http.Response{
+		StatusCode: resp.code,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(resp.body)),
+		Request:    r,
+	}, nil
+}
+
+func command(chatID int64, fromID int64, text string) *messenger.Message {
+	chatType := "private"
+	if chatID < 0 {
+		chatType = "supergroup"
+	}
+
+	return &messenger.Message{
+		From: &messenger.User{ID: fromID, FirstName: "Пётр"},
+		Chat: messenger.Chat{ID: chatID, Type: chatType, Title: "ДР"},
+		Text: text,
+	}
+}
+
+func notAdmin(s *mock_storage.MockStorage) {
+	s.EXPECT().GetAdmin(gomock.Any(), fmt.Sprint(userID)).Return(storage.AdminData{}, storage.ErrAdminNotFound)
+}
+
+func admin(role string) func(s *mock_storage.MockStorage) {
+	return func(s *mock_storage.MockStorage) {
+		s.EXPECT().GetAdmin(gomock.Any(), fmt.Sprint(adminID)).Return(storage.AdminData{ChatID: fmt.Sprint(adminID), Role: role}, nil)
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	birthday := storage.BirthdayData{
+		ID:       1,
+		UID:      "300",
+		Date:     time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		FIO:      "Иванов Иван",
+		Wishlist: "книга",
+		Code:     "code",
+	}
+	inBirthdayChat := func(s *mock_storage.MockStorage) {
+		s.EXPECT().GetBirthdayByChatID(gomock.Any(), fmt.Sprint(groupID)).Return(birthday, nil)
+	}
+	notBirthdayChat := func(s *mock_storage.MockStorage) {
+		s.EXPECT().GetBirthdayByChatID(gomock.Any(), fmt.Sprint(groupID)).Return(storage.BirthdayData{}, storage.ErrBirthdayNotFound)
+	}
+
+	tests := []struct {
+		name    string
+		message *messenger.Message
+		server  fakeServer
+		setup   []func(s *mock_storage.MockStorage)
+		// want начала сообщений, которые бот отправил в каждый чат
+		want map[int64][]string
+	}{
+		{
+			name:    "start asks for token",
+			message: command(userID, userID, "/start"),
+			want:    map[int64][]string{userID: {"Для использования этого бота необходимо авторизоваться"}},
+		},
+		{
+			name:    "start with invalid invite code",
+			message: command(userID, userID, "/start abc"),
+			setup: []func(s *mock_storage.MockStorage){func(s *mock_storage.MockStorage) {
+				s.EXPECT().CheckRegistrationCode(gomock.Any(), "abc").Return(storage.ErrRegistrationCodeInvalid)
+			}},
+			want: map[int64][]string{userID: {"Ссылка-приглашение недействительна"}},
+		},
+		{
+			name:    "start with invite code",
+			message: command(userID, userID, "/start abc"),
+			setup: []func(s *mock_storage.MockStorage){func(s *mock_storage.MockStorage) {
+				s.EXPECT().CheckRegistrationCode(gomock.Any(), "abc").Return(nil)
+			}},
+			want: map[int64][]string{userID: {"Введите ваше ФИО"}},
+		},
+		{
+			name:    "invite by not admin",
+			message: command(userID, userID, "/invite"),
+			setup:   []func(s *mock_storage.MockStorage){notAdmin},
+			want:    map[int64][]string{userID: {"Эту команду можно использовать только админу"}},
+		},
+		{
+			name:    "invite",
+			message: command(adminID, adminID, "/invite 2"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().CreateRegistrationCode(gomock.Any(), gomock.Any(), 7*24*time.Hour).Return(nil)
+			}},
+			want: map[int64][]string{adminID: {"Ссылка для регистрации (использований: 0 из 2): https://t.me/balb_bot?start="}},
+		},
+		{
+			name:    "invites none",
+			message: command(adminID, adminID, "/invites"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetActiveRegistrationCodes(gomock.Any()).Return([]storage.RegistrationCodeData{}, nil)
+			}},
+			want: map[int64][]string{adminID: {"Действующих ссылок нет"}},
+		},
+		{
+			name:    "revoke unknown",
+			message: command(adminID, adminID, "/revoke abc"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().RevokeRegistrationCode(gomock.Any(), "abc").Return(storage.ErrRegistrationCodeNotFound)
+			}},
+			want: map[int64][]string{adminID: {"Не знаю такой ссылки"}},
+		},
+		{
+			name:    "list",
+			message: command(userID, userID, "/list"),
+			server: fakeServer{
+				fmt.Sprintf("/users/get/%d", model.TelegramFront): {code: http.StatusOK, body: `[{"fio":"Иванов Иван","uid":"300"}]`},
+			},
+			want: map[int64][]string{userID: {"Пользователи:\n1. Иванов Иван - 300"}},
+		},
+		{
+			name:    "list server error",
+			message: command(userID, userID, "/list"),
+			want:    map[int64][]string{userID: {"Ошибка, попробуйте позже"}},
+		},
+		{
+			name:    "profile not registered",
+			message: command(userID, userID, "/profile"),
+			want:    map[int64][]string{userID: {"Сначала нужно зарегистрироваться"}},
+		},
+		{
+			name:    "subscribe",
+			message: command(userID, userID, "/subscribe 300"),
+			server:  fakeServer{"/subscriptions/subscribe": {code: http.StatusOK, body: `{"msg":"ok"}`}},
+			want:    map[int64][]string{userID: {"Подписка оформлена"}},
+		},
+		{
+			name:    "subscribe on self",
+			message: command(userID, userID, "/subscribe 10"),
+			server:  fakeServer{"/subscriptions/subscribe": {code: http.StatusBadRequest, body: `{"msg":"self subscription"}`}},
+			want:    map[int64][]string{userID: {"На себя подписаться нельзя"}},
+		},
+		{
+			name:    "unsubscribe not subscribed",
+			message: command(userID, userID, "/unsubscribe 300"),
+			server:  fakeServer{"/subscriptions/unsubscribe": {code: http.StatusNotFound, body: `{"msg":"subscription not found"}`}},
+			want:    map[int64][]string{userID: {"Вы не были подписаны"}},
+		},
+		{
+			name:    "wishlist updates usage",
+			message: command(userID, userID, "/wishlist_updates 300"),
+			want:    map[int64][]string{userID: {"Использование: /wishlist_updates"}},
+		},
+		{
+			name:    "wishlist updates on",
+			message: command(userID, userID, "/wishlist_updates 300 on"),
+			server:  fakeServer{"/subscriptions/wishlist": {code: http.StatusOK, body: `{"msg":"ok"}`}},
+			want:    map[int64][]string{userID: {"Уведомления об изменении вишлиста включены"}},
+		},
+		{
+			name:    "wish add usage",
+			message: command(userID, userID, "/wish_add"),
+			want:    map[int64][]string{userID: {"Использование: /wish_add"}},
+		},
+		{
+			name:    "wish add",
+			message: command(userID, userID, "/wish_add Книга | 1000 | 1"),
+			server:  fakeServer{"/wishlist/add": {code: http.StatusOK, body: `{"msg":"ok"}`}},
+			want:    map[int64][]string{userID: {"Добавлено"}},
+		},
+		{
+			name:    "wishes empty",
+			message: command(userID, userID, "/wishes"),
+			server: fakeServer{
+				fmt.Sprintf("/wishlist/get/%d/10?viewer=10", model.TelegramFront): {code: http.StatusOK, body: `[]`},
+			},
+			want: map[int64][]string{userID: {"Вишлист пуст"}},
+		},
+		{
+			name:    "gifts outside birthday chat",
+			message: command(groupID, userID, "/gifts"),
+			setup:   []func(s *mock_storage.MockStorage){notBirthdayChat},
+			want:    map[int64][]string{groupID: {"Эту команду можно использовать только в беседе дня рождения"}},
+		},
+		{
+			name:    "gifts",
+			message: command(groupID, userID, "/gifts"),
+			server: fakeServer{
+				fmt.Sprintf("/wishlist/get/%d/300?viewer=10", model.TelegramFront): {code: http.StatusOK, body: `[{"id":1,"title":"Книга","priority":2}]`},
+			},
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat},
+			want:  map[int64][]string{groupID: {"Вишлист Иванов Иван:\n1. Книга [хочу]"}},
+		},
+		{
+			name:    "collect usage",
+			message: command(groupID, userID, "/collect много"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return(fmt.Sprint(userID), nil)
+			}},
+			want: map[int64][]string{groupID: {"Использование: /collect"}},
+		},
+		{
+			name:    "collect by not organizer",
+			message: command(groupID, userID, "/collect 5000 карта 1234"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return("999", nil)
+			}, notAdmin},
+			want: map[int64][]string{groupID: {"Начать сбор может только организатор"}},
+		},
+		{
+			name:    "collect without organizer",
+			message: command(groupID, userID, "/collect 5000 карта 1234"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return("", storage.ErrOrganizerNotFound)
+			}, notAdmin},
+			want: map[int64][]string{groupID: {"Начать сбор может только организатор"}},
+		},
+		{
+			name:    "collect by admin",
+			message: command(groupID, adminID, "/collect 5000 карта 1234"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return("999", nil)
+				s.EXPECT().SetCollection(gomock.Any(), &storage.CollectionData{BirthdayID: 1, OrganizerID: fmt.Sprint(adminID), Target: 5000, Details: "карта 1234"}).Return(nil)
+				s.EXPECT().GetCollection(gomock.Any(), 1).Return(storage.CollectionData{FIO: "Иванов Иван", Target: 5000, Details: "карта 1234"}, nil)
+				s.EXPECT().SetCollectionMessageID(gomock.Any(), 1, gomock.Any()).Return(nil)
+			}, admin(storage.AdminRoleAdmin)},
+			want: map[int64][]string{groupID: {"Сбор на подарок Иванов Иван: собрано 0 из 5000"}},
+		},
+		{
+			name:    "collect changed by not collection organizer",
+			message: command(groupID, userID, "/collect 5000 карта 1234"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return(fmt.Sprint(userID), nil)
+				s.EXPECT().SetCollection(gomock.Any(), gomock.Any()).Return(storage.ErrNotOrganizer)
+			}},
+			want: map[int64][]string{groupID: {"Менять сбор может только его организатор"}},
+		},
+		{
+			name:    "chipin before collection",
+			message: command(groupID, userID, "/chipin 500"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().AddContribution(gomock.Any(), 1, storage.ContributionData{ChatID: "10", Name: "Пётр", Amount: 500}).
+					Return(storage.ErrCollectionNotFound)
+			}},
+			want: map[int64][]string{groupID: {"Сбор ещё не начат"}},
+		},
+		{
+			name:    "poll without options",
+			message: command(groupID, userID, "/poll"),
+			server: fakeServer{
+				fmt.Sprintf("/wishlist/get/%d/300?viewer=-200", model.TelegramFront): {code: http.StatusOK, body: `[]`},
+			},
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat},
+			want:  map[int64][]string{groupID: {"Для опроса нужно хотя бы 2 варианта"}},
+		},
+		{
+			name:    "handoff by not organizer",
+			message: command(groupID, userID, "/handoff @someone"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return("999", nil)
+			}, notAdmin},
+			want: map[int64][]string{groupID: {"Передать роль может только текущий организатор"}},
+		},
+		{
+			name: "handoff by reply to not invited",
+			message: func() *messenger.Message {
+				m := command(groupID, userID, "/handoff")
+				m.ReplyToMessage = &messenger.Message{From: &messenger.User{ID: 500}}
+				return m
+			}(),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return(fmt.Sprint(userID), nil)
+				s.EXPECT().GetInvitees(gomock.Any(), 1).Return([]string{fmt.Sprint(userID), "400"}, nil)
+			}},
+			want: map[int64][]string{groupID: {"Не понял кому передать"}},
+		},
+		{
+			name: "handoff by mention",
+			message: func() *messenger.Message {
+				m := command(groupID, userID, "/handoff Анна")
+				m.Mentions = []messenger.User{{ID: 400, FirstName: "Анна"}}
+				return m
+			}(),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return(fmt.Sprint(userID), nil)
+				s.EXPECT().GetInvitees(gomock.Any(), 1).Return([]string{fmt.Sprint(userID), "400"}, nil)
+				s.EXPECT().AddOrganizer(gomock.Any(), 1, "400").Return(nil)
+			}},
+			want: map[int64][]string{groupID: {"Организатор праздника"}, 400: {"Вы организатор дня рождения Иванов Иван"}},
+		},
+		{
+			name:    "pool status",
+			message: command(adminID, adminID, "/pool"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().CountFreePoolChats(gomock.Any()).Return(3, nil)
+			}},
+			want: map[int64][]string{adminID: {"Свободных бесед в пуле: 3"}},
+		},
+		{
+			name:    "pool add in private chat",
+			message: command(adminID, adminID, "/pool add"),
+			setup:   []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin)},
+			want:    map[int64][]string{adminID: {"Эту команду можно использовать только в групповых чатах"}},
+		},
+		{
+			name:    "admin by not owner",
+			message: command(adminID, adminID, "/admin list"),
+			setup:   []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin)},
+			want:    map[int64][]string{adminID: {"Эту команду можно использовать только владельцу"}},
+		},
+		{
+			name:    "admin add",
+			message: command(adminID, adminID, "/admin add 10"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleOwner), func(s *mock_storage.MockStorage) {
+				s.EXPECT().AddAdmin(gomock.Any(), "10").Return(nil)
+			}},
+			want: map[int64][]string{adminID: {"10 теперь админ"}},
+		},
+		{
+			name:    "applications none",
+			message: command(adminID, adminID, "/applications"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetPendingApplications(gomock.Any()).Return([]storage.ApplicationData{}, nil)
+			}},
+			want: map[int64][]string{adminID: {"Заявок на рассмотрении нет"}},
+		},
+		{
+			name:    "pending",
+			message: command(adminID, adminID, "/pending"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetPendingBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+			}},
+			want: map[int64][]string{adminID: {"Ждут беседу:\n- Иванов Иван (02.01), запросов: 0, код: code"}},
+		},
+		{
+			name:    "regen unknown code",
+			message: command(adminID, adminID, "/regen abc"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().RegenCode(gomock.Any(), "abc", gomock.Any(), time.Time{}).Return(storage.BirthdayData{}, storage.ErrBirthdayNotFound)
+			}},
+			want: map[int64][]string{adminID: {"Не знаю такого кода"}},
+		},
+		{
+			name:    "who without invites",
+			message: command(groupID, userID, "/who"),
+			setup: []func(s *mock_storage.MockStorage){inBirthdayChat, func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetOrganizer(gomock.Any(), 1).Return("", storage.ErrOrganizerNotFound)
+				s.EXPECT().GetBirthdayInvites(gomock.Any(), 1).Return([]storage.InviteData{}, nil)
+			}},
+			want: map[int64][]string{groupID: {"Приглашать некого"}},
+		},
+		{
+			name:    "birthday by not admin",
+			message: command(groupID, userID, "/birthday code"),
+			setup:   []func(s *mock_storage.MockStorage){notAdmin},
+			want:    map[int64][]string{userID: {"Эту команду можно использовать только админу"}},
+		},
+		{
+			name:    "birthday with unknown code",
+			message: command(groupID, adminID, "/birthday abc"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetBirthdayByCode(gomock.Any(), "abc").Return(storage.BirthdayData{}, storage.ErrBirthdayNotFound)
+			}},
+			want: map[int64][]string{adminID: {"Не знаю такого кода"}},
+		},
+		{
+			name:    "birthday without code",
+			message: command(groupID, adminID, "/birthday  "),
+			setup:   []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin)},
+			want:    map[int64][]string{adminID: {"Не знаю такого кода"}},
+		},
+		{
+			name:    "birthday already bound",
+			message: command(groupID, adminID, "/birthday code"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetBirthdayByCode(gomock.Any(), "code").Return(storage.BirthdayData{ID: 1, ChatID: "-300"}, nil)
+			}},
+			want: map[int64][]string{adminID: {"Беседа по этому коду уже привязана"}},
+		},
+		{
+			name:    "birthday bound concurrently",
+			message: command(groupID, adminID, "/birthday code"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetBirthdayByCode(gomock.Any(), "code").Return(birthday, nil)
+				s.EXPECT().UpdateLinkAndChatIDByCode(gomock.Any(), "code", fmt.Sprint(groupID), "https://t.me/+chat-200").Return(storage.ErrBirthdayNotFound)
+			}},
+			want: map[int64][]string{adminID: {"Беседа по этому коду уже привязана"}},
+		},
+		{
+			name:    "birthday",
+			message: command(groupID, adminID, "/birthday code"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetBirthdayByCode(gomock.Any(), "code").Return(birthday, nil).Times(2)
+				s.EXPECT().UpdateLinkAndChatIDByCode(gomock.Any(), "code", fmt.Sprint(groupID), "https://t.me/+chat-200").Return(nil)
+				s.EXPECT().SetWishlistMessageID(gomock.Any(), 1, 2).Return(nil)
+			}},
+			want: map[int64][]string{
+				adminID: {"Ссылка в чате по коду code успешно создана"},
+				groupID: {birthday.WishlistText()},
+			},
+		},
+		{
+			name:    "storage error",
+			message: command(adminID, adminID, "/pending"),
+			setup: []func(s *mock_storage.MockStorage){admin(storage.AdminRoleAdmin), func(s *mock_storage.MockStorage) {
+				s.EXPECT().GetPendingBirthdays(gomock.Any()).Return(nil, errors.New("db is down"))
+			}},
+			want: map[int64][]string{adminID: {"Ошибка, попробуйте позже"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			for _, setup := range tt.setup {
+				setup(s)
+			}
+			f := messenger.NewFake()
+
+			b := New(f, "token", http.Client{Transport: tt.server}, s, Config{AdminChatID: int(adminID), InviteTTL: 7 * 24 * time.Hour})
+			b.handleCommand(context.Background(), tt.message)
+
+			sent := 0
+			for chatID, want := range tt.want {
+				got := f.Texts(chatID)
+				require.Len(t, got, len(want), "chat %d: %v", chatID, got)
+				for i := range want {
+					assert.True(t, strings.HasPrefix(got[i], want[i]), "chat %d: %q", chatID, got[i])
+				}
+				sent += len(got)
+			}
+			assert.Len(t, f.Sent(), sent)
+		})
+	}
+}
+
+func TestHandleChatMember(t *testing.T) {
+	birthday := storage.BirthdayData{ID: 1, UID: "300", FIO: "Иванов Иван", ChatID: fmt.Sprint(groupID)}
+	update := &messenger.MemberUpdate{
+		Chat: messenger.Chat{ID: groupID, Type: "group"},
+		From: messenger.User{ID: userID},
+		Old:  messenger.Member{User: &messenger.User{ID: 300}, Status: "left"},
+		New:  messenger.Member{User: &messenger.User{ID: 300}, Status: "member"},
+	}
+
+	tests := []struct {
+		name    string
+		kickErr error
+		setup   func(s *mock_storage.MockStorage)
+		want    map[int64][]string
+	}{
+		{
+			name: "birthday person removed",
+			want: map[int64][]string{
+				groupID: {"Именинник оказался в беседе своего дня рождения, я его убрал"},
+				userID:  {"Не зовите Иванов Иван в беседу дня рождения"},
+			},
+		},
+		{
+			name:    "no warning if not removed",
+			kickErr: errors.New("not enough rights"),
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().SetInviteMembership(gomock.Any(), fmt.Sprint(groupID), "300", true).Return(storage.ErrInviteNotFound)
+			},
+			want: map[int64][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			s.EXPECT().GetActiveBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			f := messenger.NewFake()
+			f.Fail["Kick"] = tt.kickErr
+
+			New(f, "token", http.Client{}, s, Config{}).handleChatMember(context.Background(), update)
+
+			sent := 0
+			for chatID, want := range tt.want {
+				got := f.Texts(chatID)
+				require.Len(t, got, len(want), "chat %d: %v", chatID, got)
+				for i := range want {
+					assert.True(t, strings.HasPrefix(got[i], want[i]), "chat %d: %q", chatID, got[i])
+				}
+				sent += len(got)
+			}
+			assert.Len(t, f.Sent(), sent)
+		})
+	}
+}
+
+func TestConfirmApplicationOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock_storage.NewMockStorage(ctrl)
+	s.EXPECT().SaveApplication(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	s.EXPECT().GetAdmins(gomock.Any()).Return([]storage.AdminData{{ChatID: fmt.Sprint(adminID)}}, nil).Times(1)
+	f := messenger.NewFake()
+
+	b := New(f, "token", http.Client{}, s, Config{AdminChatID: int(adminID), Approval: true})
+	ctx := context.Background()
+	b.d.StartAuthorized(userID)
+	b.d.HandleMessage(ctx, userID, "Иванов Иван")
+	b.d.HandleMessage(ctx, userID, "02.01.1990")
+	b.d.HandleCallback(ctx, userID, 1, "date:yes")
+	b.d.HandleMessage(ctx, userID, "книга")
+
+	confirm := &messenger.Callback{ID: "1", From: messenger.User{ID: userID}, Message: &messenger.Message{MessageID: 1}, Data: "reg:confirm"}
+	b.handleCallback(ctx, confirm)
+	b.handleCallback(ctx, confirm)
+
+	assert.Len(t, f.Texts(adminID), 1)
+}
+
+func TestInviteCodeUsedOnConfirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock_storage.NewMockStorage(ctrl)
+	s.EXPECT().CheckRegistrationCode(gomock.Any(), "abc").Return(nil)
+	s.EXPECT().UseRegistrationCode(gomock.Any(), "abc").Return(nil).Times(1)
+	f := messenger.NewFake()
+
+	server := fakeServer{"/users/add": {code: http.StatusOK, body: `{"msg":"ok"}`}}
+	b := New(f, "token", http.Client{Transport: server}, s, Config{AdminChatID: int(adminID)})
+	ctx := context.Background()
+	b.handleCommand(ctx, command(userID, userID, "/start abc"))
+	b.d.HandleMessage(ctx, userID, "Иванов Иван")
+	b.d.HandleMessage(ctx, userID, "02.01.1990")
+	b.d.HandleCallback(ctx, userID, 1, "date:yes")
+	b.d.HandleMessage(ctx, userID, "книга")
+
+	confirm := &messenger.Callback{ID: "1", From: messenger.User{ID: userID}, Message: &messenger.Message{MessageID: 1}, Data: "reg:confirm"}
+	b.handleCallback(ctx, confirm)
+	b.handleCallback(ctx, confirm)
+
+	assert.True(t, b.d.IsRegistered(userID))
+}
+
+func TestTokenLockout(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		attempts int
+		base     time.Duration
+		want     time.Duration
+	}{
+		{name: "free attempts", failures: 2, attempts: 3, base: time.Minute, want: 0},
+		{name: "threshold", failures: 3, attempts: 3, base: time.Minute, want: time.Minute},
+		{name: "doubles", failures: 4, attempts: 3, base: time.Minute, want: 2 * time.Minute},
+		{name: "doubles again", failures: 6, attempts: 3, base: time.Minute, want: 8 * time.Minute},
+		{name: "capped at a day", failures: 20, attempts: 3, base: time.Minute, want: 24 * time.Hour},
+		{name: "many failures do not overflow", failures: 1000, attempts: 3, base: time.Minute, want: 24 * time.Hour},
+		{name: "base above cap", failures: 3, attempts: 3, base: 48 * time.Hour, want: 24 * time.Hour},
+		{name: "lockout disabled", failures: 10, attempts: 3, base: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenLockout(tt.failures, tt.attempts, tt.base))
+		})
+	}
+}
+
+func TestPollClosesAt(t *testing.T) {
+	birthday := storage.BirthdayData{Date: time.Date(1990, 3, 10, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "long before birthday",
+			now:  time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "closes when birthday starts",
+			now:  time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "on birthday closes at end of day",
+			now:  time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pollClosesAt(tt.now, birthday, 48*time.Hour)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, got.After(tt.now))
+		})
+	}
+}
+
+func TestOrganizerWeight(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		last      time.Time
+		organized bool
+		want      float64
+	}{
+		{name: "never organized", organized: false, want: 1},
+		{name: "half a year ago", last: now.AddDate(0, 0, -365/2), organized: true, want: float64(365/2) / 365},
+		{name: "year ago", last: now.AddDate(-1, 0, 0), organized: true, want: 1},
+		{name: "long ago", last: now.AddDate(-5, 0, 0), organized: true, want: 1},
+		{name: "just now has minimal weight", last: now, organized: true, want: 0.1},
+		{name: "clock skew", last: now.Add(time.Hour), organized: true, want: 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, organizerWeight(tt.last, tt.organized, now), 1e-9)
+		})
+	}
+}
+
+func TestPickOrganizer(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		candidates    []string
+		lastOrganized map[string]time.Time
+		// want во сколько раз чаще первого выбирают второго кандидата
+		want float64
+	}{
+		{name: "equal chances", candidates: []string{"1", "2"}, lastOrganized: map[string]time.Time{}, want: 1},
+		{name: "recent organizer is picked less", candidates: []string{"1", "2"}, lastOrganized: map[string]time.Time{"1": now}, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picked := map[string]int{}
+			for range 20000 {
+				picked[pickOrganizer(tt.candidates, tt.lastOrganized, now)]++
+			}
+
+			require.Len(t, picked, len(tt.candidates))
+			assert.InDelta(t, tt.want, float64(picked["2"])/float64(picked["1"]), tt.want*0.2)
+		})
+	}
+
+	assert.Equal(t, "1", pickOrganizer([]string{"1"}, map[string]time.Time{"1": now}, now))
+}
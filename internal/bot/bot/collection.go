@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// collect начинает сбор в беседе дня рождения или меняет его: /collect <сумма> <реквизиты>, доступно организатору и админам
+func (b *Bot) collect(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	organizer, err := b.s.GetOrganizer(ctx, birthday.ID)
+	if err != nil && !errors.Is(err, storage.ErrOrganizerNotFound) {
+		log.Err(err).Msg("error getting organizer")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	if organizer != fmt.Sprint(message.From.ID) && !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.Chat.ID, "Начать сбор может только организатор")
+		b.a.Send(msg)
+		return
+	}
+
+	targetStr, details, _ := strings.Cut(strings.TrimSpace(message.CommandArguments()), " ")
+	target, err := strconv.Atoi(targetStr)
+	if err != nil || target <= 0 || strings.TrimSpace(details) == "" {
+		msg := messenger.Text(message.Chat.ID, "Использование: /collect <сумма> <реквизиты для перевода>")
+		b.a.Send(msg)
+		return
+	}
+
+	err = b.s.SetCollection(ctx, &storage.CollectionData{
+		BirthdayID:  birthday.ID,
+		OrganizerID: fmt.Sprint(message.From.ID),
+		Target:      target,
+		Details:     strings.TrimSpace(details),
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotOrganizer) {
+			msg := messenger.Text(message.Chat.ID, "Менять сбор может только его организатор")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error setting collection")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	b.updateCollectionSummary(ctx, message.Chat.ID, birthday.ID)
+}
+
+// chipin записывает взнос: /chipin <сумма>
+func (b *Bot) chipin(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	amount, err := strconv.Atoi(strings.TrimSpace(message.CommandArguments()))
+	if err != nil || amount <= 0 {
+		msg := messenger.Text(message.Chat.ID, "Использование: /chipin <сумма>")
+		b.a.Send(msg)
+		return
+	}
+
+	err = b.s.AddContribution(ctx, birthday.ID, storage.ContributionData{
+		ChatID: fmt.Sprint(message.From.ID),
+		Name:   displayName(message.From),
+		Amount: amount,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrCollectionNotFound) {
+			msg := messenger.Text(message.Chat.ID, "Сбор ещё не начат, организатор может начать его командой /collect")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error adding contribution")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	b.updateCollectionSummary(ctx, message.Chat.ID, birthday.ID)
+}
+
+// updateCollectionSummary обновляет закреплённую сводку по сбору, если её ещё нет - отправляет и закрепляет
+func (b *Bot) updateCollectionSummary(ctx context.Context, chatID int64, birthdayID int) {
+	collection, err := b.s.GetCollection(ctx, birthdayID)
+	if err != nil {
+		log.Err(err).Msg("error getting collection")
+		return
+	}
+
+	text := collectionText(collection)
+	if collection.MessageID != 0 {
+		if err = b.a.Edit(messenger.Edit(chatID, collection.MessageID, text)); err == nil {
+			return
+		}
+		log.Err(err).Msg("error editing collection summary, sending new one")
+	}
+
+	messageID, err := b.a.Send(messenger.Text(chatID, text))
+	if err != nil {
+		log.Err(err).Msg("error sending collection summary")
+		return
+	}
+
+	if err = b.a.Pin(chatID, messageID); err != nil {
+		log.Err(err).Msg("error pinning collection summary")
+	}
+
+	if err = b.s.SetCollectionMessageID(ctx, birthdayID, messageID); err != nil {
+		log.Err(err).Msg("error saving collection message id")
+	}
+}
+
+func collectionText(c storage.CollectionData) string {
+	lines := []string{
+		fmt.Sprintf("Сбор на подарок %s: собрано %d из %d", c.FIO, c.Collected(), c.Target),
+		fmt.Sprintf("Реквизиты: %s", c.Details),
+		"Отметить взнос: /chipin <сумма>",
+	}
+
+	if len(c.Contributions) > 0 {
+		lines = append(lines, "", "Скинулись:")
+		for _, contribution := range c.Contributions {
+			lines = append(lines, fmt.Sprintf("- %s: %d", contribution.Name, contribution.Amount))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func displayName(user *messenger.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if name == "" {
+		return user.UserName
+	}
+
+	return name
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// handleJoinRequest одобряет заявки на вступление по личным ссылкам только от приглашённых, именинника и чужих отклоняет.
+// Заявки по ссылкам, которые создавал не бот, остаются на усмотрение админов беседы
+func (b *Bot) handleJoinRequest(ctx context.Context, request *messenger.JoinRequest) {
+	if request.InviteLink == "" {
+		return
+	}
+
+	invite, err := b.s.GetInviteByLink(ctx, request.InviteLink)
+	if err != nil {
+		if !errors.Is(err, storage.ErrInviteNotFound) {
+			log.Err(err).Msg("error getting invite by link")
+		}
+		return
+	}
+
+	userID := fmt.Sprint(request.From.ID)
+	approve := userID == invite.ChatID
+	if !approve && userID != invite.UID {
+		invitees, err := b.s.GetInvitees(ctx, invite.BirthdayID)
+		if err != nil {
+			log.Err(err).Msg("error getting invitees")
+			return
+		}
+		approve = slices.Contains(invitees, userID)
+	}
+
+	if !approve {
+		log.Info().Str("uid", userID).Int("birthday", invite.BirthdayID).Msg("declining join request")
+	}
+	if err = b.a.AnswerJoinRequest(request.Chat.ID, request.From.ID, approve); err != nil {
+		log.Err(err).Msg("error answering join request")
+	}
+}
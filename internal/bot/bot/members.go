@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+func inChat(member messenger.Member) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	default:
+		return false
+	}
+}
+
+// handleChatMember отмечает, кто из приглашённых зашёл в беседу или вышел из неё, и не пускает именинника в свою беседу
+func (b *Bot) handleChatMember(ctx context.Context, update *messenger.MemberUpdate) {
+	wasIn, isIn := inChat(update.Old), inChat(update.New)
+	if wasIn == isIn || update.New.User == nil {
+		return
+	}
+
+	if isIn && b.removeBirthdayPerson(ctx, update) {
+		return
+	}
+
+	err := b.s.SetInviteMembership(ctx, fmt.Sprint(update.Chat.ID), fmt.Sprint(update.New.User.ID), isIn)
+	if err != nil && !errors.Is(err, storage.ErrInviteNotFound) {
+		log.Err(err).Msg("error saving invite membership")
+	}
+}
+
+// removeBirthdayPerson убирает именинника, если он оказался в беседе своего дня рождения, и предупреждает того, кто его добавил
+func (b *Bot) removeBirthdayPerson(ctx context.Context, update *messenger.MemberUpdate) bool {
+	// В режиме форума в одной супергруппе несколько дней рождения, поэтому проверяем все
+	birthdays, err := b.s.GetActiveBirthdays(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting active birthdays")
+		return false
+	}
+
+	user := update.New.User
+	for _, birthday := range birthdays {
+		if birthday.ChatID != fmt.Sprint(update.Chat.ID) || birthday.UID != fmt.Sprint(user.ID) {
+			continue
+		}
+
+		// Предупреждаем только если именинника действительно убрали
+		if err = b.a.Kick(update.Chat.ID, user.ID); err != nil {
+			log.Err(err).Msg("error removing birthday person from own chat")
+			return false
+		}
+
+		if birthday.ThreadID == 0 {
+			msg := messenger.Text(update.Chat.ID, "Именинник оказался в беседе своего дня рождения, я его убрал")
+			b.a.Send(msg)
+		}
+
+		if update.From.ID != user.ID {
+			msg := messenger.Text(update.From.ID, fmt.Sprintf("Не зовите %s в беседу дня рождения, это же сюрприз. Я убрал его оттуда", birthday.FIO))
+			if _, err = b.a.Send(msg); err != nil {
+				log.Err(err).Msg("error warning birthday person adder")
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// who показывает в беседе, кто из приглашённых зашёл, а кто нет
+func (b *Bot) who(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	organizer, err := b.s.GetOrganizer(ctx, birthday.ID)
+	if err != nil && !errors.Is(err, storage.ErrOrganizerNotFound) {
+		log.Err(err).Msg("error getting organizer")
+	}
+	// Пока организатора нет, сводку может посмотреть любой
+	if organizer != "" && organizer != fmt.Sprint(message.From.ID) && !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.Chat.ID, "Сводку может посмотреть только организатор")
+		b.a.Send(msg)
+		return
+	}
+
+	invites, err := b.s.GetBirthdayInvites(ctx, birthday.ID)
+	if err != nil {
+		log.Err(err).Msg("error getting birthday invites")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.HTML(message.Chat.ID, b.whoText(invites))
+	b.a.Send(msg)
+}
+
+func (b *Bot) whoText(invites []storage.InviteData) string {
+	if len(invites) == 0 {
+		return "Приглашать некого, на именинника никто не подписан"
+	}
+
+	joined, left, notJoined := []string{}, []string{}, []string{}
+	for _, invite := range invites {
+		userID, err := strconv.ParseInt(invite.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		switch {
+		case invite.JoinedAt != nil && (invite.LeftAt == nil || invite.LeftAt.Before(*invite.JoinedAt)):
+			joined = append(joined, b.mention(userID))
+		case invite.JoinedAt != nil:
+			left = append(left, b.mention(userID))
+		case invite.Status == storage.InviteDone:
+			notJoined = append(notJoined, b.mention(userID))
+		default:
+			notJoined = append(notJoined, b.mention(userID)+" (приглашение ещё не отправлено)")
+		}
+	}
+
+	lines := []string{fmt.Sprintf("Зашли %d из %d", len(joined), len(invites))}
+	for _, group := range []struct {
+		title string
+		users []string
+	}{{"В беседе:", joined}, {"Не зашли:", notJoined}, {"Вышли:", left}} {
+		if len(group.users) > 0 {
+			lines = append(lines, "", group.title)
+			for _, user := range group.users {
+				lines = append(lines, "- "+user)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// canWork может ли бот вести беседу: без админа с правом приглашать ссылку не создать
+func canWork(member messenger.Member) bool {
+	return member.IsCreator() || member.IsAdministrator() && member.CanInviteUsers
+}
+
+// handleMyChatMember следит за правами бота в беседах: если их забрали или бота удалили, приглашения
+// приостанавливаются, а админу приходит инструкция; если права вернули - приглашения продолжаются
+func (b *Bot) handleMyChatMember(ctx context.Context, update *messenger.MemberUpdate) {
+	could, can := canWork(update.Old), canWork(update.New)
+	if could == can {
+		return
+	}
+
+	chatID := fmt.Sprint(update.Chat.ID)
+
+	if !can {
+		if !inChat(update.New) {
+			if err := b.s.RemovePoolChat(ctx, chatID); err != nil && !errors.Is(err, storage.ErrPoolChatNotFound) {
+				log.Err(err).Msg("error removing pool chat")
+			}
+		}
+
+		b.breakChat(ctx, update.Chat, "Меня удалили или лишили админа")
+		return
+	}
+
+	link, err := b.a.ChatLink(update.Chat.ID)
+	if err != nil {
+		log.Err(err).Msg("error getting restored chat invite link")
+		return
+	}
+
+	birthdays, err := b.s.SetChatBroken(ctx, chatID, false, link)
+	if err != nil {
+		log.Err(err).Msg("error marking chat restored")
+		return
+	}
+
+	for _, birthday := range birthdays {
+		b.notifyAdmins(ctx, fmt.Sprintf("Права в беседе дня рождения %s (%s) вернули, продолжаю рассылать приглашения", birthday.FIO, birthday.Date.Format("02.01")))
+	}
+}
+
+// breakChat приостанавливает приглашения в беседе, где бот не может создавать ссылки, и просит админа это исправить,
+// reason - что случилось, с большой буквы
+func (b *Bot) breakChat(ctx context.Context, chat messenger.Chat, reason string) {
+	birthdays, err := b.s.SetChatBroken(ctx, fmt.Sprint(chat.ID), true, "")
+	if err != nil {
+		log.Err(err).Msg("error marking chat broken")
+		return
+	}
+
+	for _, birthday := range birthdays {
+		b.notifyAdmins(ctx, fmt.Sprintf("%s в беседе дня рождения %s (%s) \"%s\", приглашения приостановлены. "+
+			"Верните мне админа с правом приглашать участников или создайте новую беседу, дайте мне там админа и введите в ней '/birthday %s'",
+			reason, birthday.FIO, birthday.Date.Format("02.01"), chat.Title, birthday.Code))
+	}
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+const organizerChecklist = `Вы организатор дня рождения %s (%s), что стоит сделать:
+1. Начать сбор в беседе: /collect <сумма> <реквизиты>
+2. Выбрать подарок: /gifts или /poll
+3. Договориться, кто и когда вручает подарок
+Если не получается, передайте роль в беседе: /handoff @username`
+
+// organizerWeight вес кандидата при выборе организатора: кто организовывал недавно, у того шанс меньше,
+// через год после последнего раза вес восстанавливается полностью
+func organizerWeight(lastOrganized time.Time, organized bool, now time.Time) float64 {
+	if !organized {
+		return 1
+	}
+
+	years := now.Sub(lastOrganized).Hours() / 24 / 365
+	return min(max(years, 0.1), 1)
+}
+
+func pickOrganizer(candidates []string, lastOrganized map[string]time.Time, now time.Time) string {
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, candidate := range candidates {
+		last, organized := lastOrganized[candidate]
+		weights[i] = organizerWeight(last, organized, now)
+		total += weights[i]
+	}
+
+	r := rand.Float64() * total
+	for i, weight := range weights {
+		if r < weight {
+			return candidates[i]
+		}
+		r -= weight
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// electOrganizer выбирает организатора среди приглашённых, объявляет его в беседе и присылает ему памятку
+func (b *Bot) electOrganizer(ctx context.Context, chatID int64, birthday storage.BirthdayData) {
+	invitees, err := b.s.GetInvitees(ctx, birthday.ID)
+	if err != nil {
+		log.Err(err).Msg("error getting invitees")
+		return
+	}
+
+	candidates := []string{}
+	for _, invitee := range invitees {
+		if invitee != birthday.UID {
+			candidates = append(candidates, invitee)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	lastOrganized, err := b.s.GetLastOrganized(ctx, candidates)
+	if err != nil {
+		log.Err(err).Msg("error getting organizers history")
+		return
+	}
+
+	organizer := pickOrganizer(candidates, lastOrganized, time.Now())
+	b.setOrganizer(ctx, chatID, birthday, organizer)
+}
+
+func (b *Bot) setOrganizer(ctx context.Context, chatID int64, birthday storage.BirthdayData, organizer string) {
+	organizerID, err := strconv.ParseInt(organizer, 10, 64)
+	if err != nil {
+		log.Err(err).Msg("error convering chat id, should be impossible")
+		return
+	}
+
+	if err = b.s.AddOrganizer(ctx, birthday.ID, organizer); err != nil {
+		log.Err(err).Msg("error saving organizer")
+		return
+	}
+
+	msg := messenger.Text(
+		chatID,
+		fmt.Sprintf("Организатор праздника - %s. Передать роль можно командой /handoff @username", b.mention(organizerID)),
+	)
+	msg.HTML = true
+	b.a.Send(msg)
+
+	msg = messenger.Text(organizerID, fmt.Sprintf(organizerChecklist, birthday.FIO, birthday.Next(time.Now()).Format("02.01")))
+	if _, err = b.a.Send(msg); err != nil {
+		log.Err(err).Msg("error sending organizer checklist")
+	}
+}
+
+// handoff передаёт роль организатора: /handoff @username или ответом на сообщение
+func (b *Bot) handoff(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	organizer, err := b.s.GetOrganizer(ctx, birthday.ID)
+	if err != nil && !errors.Is(err, storage.ErrOrganizerNotFound) {
+		log.Err(err).Msg("error getting organizer")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	if organizer != fmt.Sprint(message.From.ID) && !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.Chat.ID, "Передать роль может только текущий организатор")
+		b.a.Send(msg)
+		return
+	}
+
+	target, err := b.handoffTarget(ctx, message, birthday.ID)
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Не понял кому передать, используйте /handoff @username или ответьте командой на сообщение, "+
+			"организатором может быть только приглашённый")
+		b.a.Send(msg)
+		return
+	}
+
+	if fmt.Sprint(target) == birthday.UID {
+		msg := messenger.Text(message.Chat.ID, "Имениннику организовывать свой праздник нельзя")
+		b.a.Send(msg)
+		return
+	}
+
+	b.setOrganizer(ctx, message.Chat.ID, birthday, fmt.Sprint(target))
+}
+
+// handoffTarget кому передать роль: автор сообщения, на которое ответили, упомянутый без username или @username.
+// Организатором может быть только приглашённый, поэтому цель ищем среди них
+func (b *Bot) handoffTarget(ctx context.Context, message *messenger.Message, birthdayID int) (int64, error) {
+	var target int64
+	username := strings.TrimPrefix(strings.TrimSpace(message.CommandArguments()), "@")
+	switch {
+	case message.ReplyToMessage != nil && message.ReplyToMessage.From != nil:
+		target = message.ReplyToMessage.From.ID
+	case len(message.Mentions) > 0:
+		target = message.Mentions[0].ID
+	case username == "":
+		return 0, errors.New("no handoff target")
+	}
+
+	invitees, err := b.s.GetInvitees(ctx, birthdayID)
+	if err != nil {
+		return 0, err
+	}
+	for _, invitee := range invitees {
+		inviteeID, err := strconv.ParseInt(invitee, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if target != 0 {
+			if inviteeID == target {
+				return target, nil
+			}
+			continue
+		}
+
+		// Бот знает только chat id приглашённых, поэтому username сверяем с каждым
+		chat, err := b.a.User(inviteeID)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(chat.UserName, username) {
+			return inviteeID, nil
+		}
+	}
+
+	return 0, errors.New("handoff target not found")
+}
+
+// mention ссылка на пользователя для сообщений с ParseMode HTML
+func (b *Bot) mention(userID int64) string {
+	name := fmt.Sprint(userID)
+	chat, err := b.a.User(userID)
+	if err == nil {
+		if fullName := strings.TrimSpace(chat.FirstName + " " + chat.LastName); fullName != "" {
+			name = fullName
+		}
+	}
+
+	return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, userID, html.EscapeString(name))
+}
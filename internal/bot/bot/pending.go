@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// pending показывает админу дни рождения, для которых ещё не создана беседа
+func (b *Bot) pending(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	birthdays, err := b.s.GetPendingBirthdays(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting pending birthdays")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	if len(birthdays) == 0 {
+		msg := messenger.Text(message.From.ID, "Все беседы созданы")
+		b.a.Send(msg)
+		return
+	}
+
+	now := time.Now().UTC()
+	lines := []string{"Ждут беседу:"}
+	for _, birthday := range birthdays {
+		line := fmt.Sprintf("- %s (%s), запросов: %d, код: %s", birthday.FIO, birthday.Date.Format("02.01"), birthday.RequestCount, birthday.Code)
+		if birthday.CodeExpired(now) {
+			line += " (истёк, /regen " + birthday.Code + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	msg := messenger.Text(message.From.ID, strings.Join(lines, "\n"))
+	b.a.Send(msg)
+}
+
+// regen выдаёт новый код привязки беседы взамен старого: /regen <код>
+func (b *Bot) regen(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		log.Err(err).Msg("error generating uuid")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	oldCode := strings.TrimSpace(message.CommandArguments())
+	birthday, err := b.s.RegenCode(ctx, oldCode, uuid.String(), storage.CodeExpiry(time.Now().UTC(), b.cfg.CodeTTL))
+	if err != nil {
+		if errors.Is(err, storage.ErrBirthdayNotFound) {
+			msg := messenger.Text(message.From.ID, "Не знаю такого кода или беседа уже привязана, список ожидающих беседу: /pending")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error regenerating code")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(
+		message.From.ID,
+		fmt.Sprintf("Новый код для дня рождения %s (%s), создайте чат, дайте мне там админа и введите в нём команду '/birthday %s'",
+			birthday.FIO, birthday.Date.Format("02.01"), birthday.Code),
+	)
+	b.a.Send(msg)
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// Ограничения Telegram на опросы
+const (
+	minPollOptions   = 2
+	maxPollOptions   = 10
+	maxPollOptionLen = 100
+)
+
+var errNotEnoughOptions = errors.New("not enough poll options")
+
+// poll создаёт в беседе опрос о подарке из вишлиста именинника и свободных вариантов: /poll [вариант; вариант]
+func (b *Bot) poll(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	extra := []string{}
+	for _, option := range strings.Split(message.CommandArguments(), ";") {
+		if option = strings.TrimSpace(option); option != "" {
+			extra = append(extra, option)
+		}
+	}
+
+	if err = b.startPoll(ctx, message.Chat.ID, birthday, extra); err != nil {
+		if errors.Is(err, errNotEnoughOptions) {
+			msg := messenger.Text(message.Chat.ID, "Для опроса нужно хотя бы 2 варианта, добавьте свои: /poll вариант; вариант")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error starting poll")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+	}
+}
+
+func (b *Bot) startPoll(ctx context.Context, chatID int64, birthday storage.BirthdayData, extra []string) error {
+	options := []string{}
+	seen := map[string]bool{}
+	addOption := func(option string) {
+		option = messenger.Truncate(option, maxPollOptionLen)
+		if len(options) < maxPollOptions && !seen[option] {
+			seen[option] = true
+			options = append(options, option)
+		}
+	}
+
+	// Смотрим вишлист от имени беседы, чтобы видеть занятые подарки и не добавлять их в опрос
+	items, err := b.getWishlistItems(birthday.UID, fmt.Sprint(chatID))
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist items for poll")
+	}
+	for _, item := range items {
+		if item.ClaimedBy == "" {
+			addOption(item.Title)
+		}
+	}
+	for _, option := range extra {
+		addOption(option)
+	}
+
+	if len(options) < minPollOptions {
+		return errNotEnoughOptions
+	}
+
+	closesAt := pollClosesAt(time.Now().UTC(), birthday, b.cfg.PollDuration)
+	question := fmt.Sprintf("Что дарим %s? Опрос закроется %s", birthday.FIO, closesAt.Format("02.01 15:04"))
+	messageID, err := b.a.SendPoll(chatID, question, options)
+	if err != nil {
+		return err
+	}
+
+	return b.s.CreatePoll(ctx, &storage.PollData{
+		BirthdayID: birthday.ID,
+		ChatID:     fmt.Sprint(chatID),
+		MessageID:  messageID,
+		ClosesAt:   closesAt,
+	})
+}
+
+// pollClosesAt когда закрыть опрос: через duration, но не позже начала дня рождения, а в сам день рождения - до конца дня
+func pollClosesAt(now time.Time, birthday storage.BirthdayData, duration time.Duration) time.Time {
+	closesAt := now.Add(duration)
+
+	deadline := birthday.Next(now)
+	if !deadline.After(now) {
+		deadline = deadline.AddDate(0, 0, 1)
+	}
+	if deadline.Before(closesAt) {
+		closesAt = deadline
+	}
+
+	return closesAt
+}
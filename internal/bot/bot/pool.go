@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// pool добавляет беседу в пул заранее созданных: /pool add, без аргументов показывает сколько бесед свободно
+func (b *Bot) pool(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	if strings.TrimSpace(message.CommandArguments()) != "add" {
+		free, err := b.s.CountFreePoolChats(ctx)
+		if err != nil {
+			log.Err(err).Msg("error counting pool chats")
+			msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+			b.a.Send(msg)
+			return
+		}
+
+		msg := messenger.Text(message.From.ID, fmt.Sprintf("Свободных бесед в пуле: %d, добавить беседу можно командой /pool add в ней", free))
+		b.a.Send(msg)
+		return
+	}
+
+	if !message.Chat.IsGroup() && !message.Chat.IsSuperGroup() {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только в групповых чатах")
+		b.a.Send(msg)
+		return
+	}
+
+	if _, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID)); err == nil {
+		msg := messenger.Text(message.From.ID, "Эта беседа уже привязана к дню рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	// Без этих прав бот не сможет сам оформить беседу и позвать в неё гостей
+	me, err := b.a.ChatMember(message.Chat.ID, b.a.Me().ID)
+	if err != nil || !me.IsAdministrator() || !me.CanInviteUsers || !me.CanChangeInfo || !me.CanPinMessages {
+		msg := messenger.Text(message.From.ID, "Дайте мне в беседе админа с правами приглашать участников, менять информацию и закреплять сообщения")
+		b.a.Send(msg)
+		return
+	}
+
+	if err = b.s.AddPoolChat(ctx, fmt.Sprint(message.Chat.ID)); err != nil {
+		if errors.Is(err, storage.ErrChatAlreadyInPool) {
+			msg := messenger.Text(message.From.ID, "Эта беседа уже в пуле")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error adding pool chat")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(message.From.ID, fmt.Sprintf("Беседа %s добавлена в пул, я сам займу её под ближайший день рождения", message.Chat.Title))
+	b.a.Send(msg)
+}
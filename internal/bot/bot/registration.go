@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// start начинает регистрацию, /start <код> из ссылки-приглашения пропускает ввод токена
+func (b *Bot) start(ctx context.Context, message *messenger.Message) {
+	if b.cfg.Approval {
+		b.startApplication(ctx, message)
+		return
+	}
+
+	code := strings.TrimSpace(message.CommandArguments())
+	if code == "" {
+		msg := messenger.Text(message.From.ID, "Для использования этого бота необходимо авторизоваться, введите токен")
+		b.d.Reset(message.From.ID)
+		b.a.Send(msg)
+		return
+	}
+
+	if b.d.IsRegistered(message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Вы уже зарегистрированы, посмотреть свои данные можно командой /profile")
+		b.a.Send(msg)
+		return
+	}
+
+	// Использование засчитывается только после регистрации, чтобы брошенная анкета не съедала ссылку
+	if err := b.s.CheckRegistrationCode(ctx, code); err != nil {
+		if !errors.Is(err, storage.ErrRegistrationCodeInvalid) {
+			log.Err(err).Msg("error checking registration code")
+		}
+
+		msg := messenger.Text(message.From.ID, "Ссылка-приглашение недействительна, попросите новую или введите токен")
+		b.d.Reset(message.From.ID)
+		b.a.Send(msg)
+		return
+	}
+
+	b.a.Send(b.d.StartInvited(message.From.ID, code))
+}
+
+// useInviteCode засчитывает ссылку-приглашение, по которой пользователь только что зарегистрировался
+func (b *Bot) useInviteCode(ctx context.Context, chatID int64) {
+	code := b.d.TakeInviteCode(chatID)
+	if code == "" {
+		return
+	}
+
+	// Ссылку могли исчерпать или отозвать, пока человек заполнял анкету, регистрацию это уже не отменяет
+	if err := b.s.UseRegistrationCode(ctx, code); err != nil {
+		log.Err(err).Int64("chat_id", chatID).Msg("error using registration code")
+	}
+}
+
+// startApplication в режиме одобрения начинает анкету сразу, без токена и кодов
+func (b *Bot) startApplication(ctx context.Context, message *messenger.Message) {
+	if b.d.IsRegistered(message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Вы уже зарегистрированы, посмотреть свои данные можно командой /profile")
+		b.a.Send(msg)
+		return
+	}
+
+	if text, ok := b.applicationStatus(ctx, message.From.ID); ok {
+		msg := messenger.Text(message.From.ID, text)
+		b.a.Send(msg)
+		return
+	}
+
+	b.a.Send(b.d.StartAuthorized(message.From.ID))
+}
+
+// invite создаёт ссылку-приглашение на регистрацию: /invite [сколько раз можно использовать] [сколько действует, например 72h]
+func (b *Bot) invite(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	maxUses, ttl := 1, b.cfg.InviteTTL
+	args := strings.Fields(message.CommandArguments())
+	var err error
+	if len(args) > 0 {
+		maxUses, err = strconv.Atoi(args[0])
+	}
+	if err == nil && len(args) > 1 {
+		ttl, err = time.ParseDuration(args[1])
+	}
+	if err != nil || maxUses <= 0 || ttl < 0 || len(args) > 2 {
+		msg := messenger.Text(message.From.ID, "Использование: /invite [сколько раз можно использовать, по умолчанию 1] [сколько действует, например 72h, 0 - бессрочно]")
+		b.a.Send(msg)
+		return
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		log.Err(err).Msg("error generating uuid")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	code := &storage.RegistrationCodeData{Code: uuid.String(), CreatedBy: fmt.Sprint(message.From.ID), MaxUses: maxUses}
+	if err = b.s.CreateRegistrationCode(ctx, code, ttl); err != nil {
+		log.Err(err).Msg("error creating registration code")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(
+		message.From.ID,
+		fmt.Sprintf("Ссылка для регистрации (%s): %s\nОтозвать: /revoke %s", registrationCodeLimits(*code), b.registrationLink(code.Code), code.Code),
+	)
+	b.a.Send(msg)
+}
+
+// invites показывает действующие ссылки-приглашения
+func (b *Bot) invites(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	codes, err := b.s.GetActiveRegistrationCodes(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting registration codes")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	if len(codes) == 0 {
+		msg := messenger.Text(message.From.ID, "Действующих ссылок нет, создать: /invite")
+		b.a.Send(msg)
+		return
+	}
+
+	lines := []string{"Действующие ссылки:"}
+	for _, code := range codes {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", b.registrationLink(code.Code), registrationCodeLimits(code)))
+	}
+
+	msg := messenger.Text(message.From.ID, strings.Join(lines, "\n"))
+	b.a.Send(msg)
+}
+
+// revoke отзывает ссылку-приглашение: /revoke <код>
+func (b *Bot) revoke(ctx context.Context, message *messenger.Message) {
+	if !b.isAdmin(ctx, message.From.ID) {
+		msg := messenger.Text(message.From.ID, "Эту команду можно использовать только админу")
+		b.a.Send(msg)
+		return
+	}
+
+	err := b.s.RevokeRegistrationCode(ctx, strings.TrimSpace(message.CommandArguments()))
+	if err != nil {
+		if errors.Is(err, storage.ErrRegistrationCodeNotFound) {
+			msg := messenger.Text(message.From.ID, "Не знаю такой ссылки, действующие: /invites")
+			b.a.Send(msg)
+			return
+		}
+
+		log.Err(err).Msg("error revoking registration code")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(message.From.ID, "Ссылка отозвана")
+	b.a.Send(msg)
+}
+
+func (b *Bot) registrationLink(code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", b.a.Me().UserName, code)
+}
+
+func registrationCodeLimits(code storage.RegistrationCodeData) string {
+	limits := fmt.Sprintf("использований: %d из %d", code.Uses, code.MaxUses)
+	if code.ExpiresAt != nil {
+		limits += fmt.Sprintf(", действует до %s", code.ExpiresAt.Format("02.01 15:04"))
+	}
+
+	return limits
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/smakimka/balb/internal/model"
+)
+
+const serverURL = "http://server:8090"
+
+// postJSON отправляет data на сервер, при ответе не 200 возвращает код и сообщение сервера
+func (b *Bot) postJSON(path string, data any, result any) (int, model.Response, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return 0, model.Response{}, err
+	}
+
+	resp, err := b.c.Post(serverURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, model.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, result)
+}
+
+func (b *Bot) getJSON(path string, result any) (int, model.Response, error) {
+	resp, err := b.c.Get(serverURL + path)
+	if err != nil {
+		return 0, model.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, result)
+}
+
+func decodeResponse(resp *http.Response, result any) (int, model.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, model.Response{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var response model.Response
+		if err = json.Unmarshal(body, &response); err != nil {
+			return resp.StatusCode, response, fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return resp.StatusCode, response, nil
+	}
+
+	if result != nil {
+		if err = json.Unmarshal(body, result); err != nil {
+			return resp.StatusCode, model.Response{}, err
+		}
+	}
+
+	return resp.StatusCode, model.Response{}, nil
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/dialog"
+	"github.com/smakimka/balb/internal/bot/messenger"
+)
+
+const (
+	// maxTokenLockout дольше суток ввод токена не блокируется, сколько бы ни было ошибок
+	maxTokenLockout = 24 * time.Hour
+	// tokenWindow за какое время считаются неверные токены всех чатов вместе
+	tokenWindow = time.Minute
+)
+
+// tokenGuard ограничивает подбор токена: после TokenAttempts ошибок подряд блокирует чат на TokenLockout,
+// дальше каждая ошибка удваивает блокировку. Блокировки хранятся в storage и переживают рестарт.
+// Если во всех чатах вместе за минуту больше TokenGlobalLimit ошибок, ввод токена приостанавливается для всех.
+// Общая пауза и окно ошибок живут только в памяти: рестарт их сбрасывает, но пауза всего на минуту,
+// а блокировки отдельных чатов при этом сохраняются
+type tokenGuard struct {
+	b *Bot
+
+	m           sync.Mutex
+	failures    []time.Time
+	pausedUntil time.Time
+}
+
+func (g *tokenGuard) Wait(ctx context.Context, chatID int64) time.Duration {
+	g.m.Lock()
+	paused := time.Until(g.pausedUntil)
+	g.m.Unlock()
+
+	lock, err := g.b.s.GetTokenLock(ctx, fmt.Sprint(chatID))
+	if err != nil {
+		// Не зная, заблокирован ли чат, лучше не давать пробовать
+		log.Err(err).Msg("error getting token lock")
+		return tokenWindow
+	}
+
+	return max(lock, paused, 0)
+}
+
+func (g *tokenGuard) Failed(ctx context.Context, chatID int64) time.Duration {
+	paused := g.countFailure(ctx)
+
+	failures, err := g.b.s.AddTokenFailure(ctx, fmt.Sprint(chatID))
+	if err != nil {
+		log.Err(err).Msg("error adding token failure")
+		return paused
+	}
+
+	lock := tokenLockout(failures, g.b.cfg.TokenAttempts, g.b.cfg.TokenLockout)
+	if lock == 0 {
+		return paused
+	}
+
+	if err = g.b.s.SetTokenLock(ctx, fmt.Sprint(chatID), lock); err != nil {
+		log.Err(err).Msg("error setting token lock")
+	}
+
+	log.Warn().Int64("chat_id", chatID).Int("failures", failures).Dur("lock", lock).Msg("registration token locked")
+	g.b.alertAdmins(ctx, fmt.Sprintf(
+		"%s (%d) ввёл неверный токен %d раз подряд, ввод заблокирован на %s",
+		g.b.mention(chatID), chatID, failures, dialog.WaitText(lock),
+	))
+
+	return max(lock, paused)
+}
+
+func (g *tokenGuard) Passed(ctx context.Context, chatID int64) {
+	if err := g.b.s.ResetTokenFailures(ctx, fmt.Sprint(chatID)); err != nil {
+		log.Err(err).Msg("error resetting token failures")
+	}
+}
+
+// countFailure учитывает ошибку в общем окне и, если их слишком много, приостанавливает ввод токена для всех
+func (g *tokenGuard) countFailure(ctx context.Context) time.Duration {
+	if g.b.cfg.TokenGlobalLimit <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+
+	g.m.Lock()
+	i := 0
+	for i < len(g.failures) && now.Sub(g.failures[i]) > tokenWindow {
+		i++
+	}
+	g.failures = append(g.failures[i:], now)
+
+	tripped := len(g.failures) > g.b.cfg.TokenGlobalLimit && !now.Before(g.pausedUntil)
+	if tripped {
+		g.pausedUntil = now.Add(tokenWindow)
+	}
+	paused := g.pausedUntil.Sub(now)
+	g.m.Unlock()
+
+	if tripped {
+		log.Warn().Int("limit", g.b.cfg.TokenGlobalLimit).Msg("registration token paused for everyone")
+		g.b.alertAdmins(ctx, fmt.Sprintf(
+			"За минуту больше %d неверных токенов, похоже его подбирают. Ввод токена приостановлен для всех на минуту, возможно, токен стоит сменить",
+			g.b.cfg.TokenGlobalLimit,
+		))
+	}
+
+	return max(paused, 0)
+}
+
+// tokenLockout на сколько блокировать ввод после failures ошибок подряд, первые attempts ошибок бесплатные
+func tokenLockout(failures int, attempts int, base time.Duration) time.Duration {
+	if failures < attempts || base <= 0 {
+		return 0
+	}
+
+	lock := min(base, maxTokenLockout)
+	for range failures - attempts {
+		lock *= 2
+		if lock >= maxTokenLockout {
+			return maxTokenLockout
+		}
+	}
+
+	return lock
+}
+
+// alertAdmins предупреждает всех админов о подозрительной активности
+func (b *Bot) alertAdmins(ctx context.Context, text string) {
+	for _, adminChatID := range b.adminChatIDs(ctx) {
+		msg := messenger.HTML(adminChatID, text)
+		if _, err := b.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending admin alert")
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/model"
+)
+
+var errWrongItem = errors.New("wrong wishlist item")
+
+var priorityNames = map[int]string{
+	model.PriorityHigh:   "очень хочу",
+	model.PriorityMedium: "хочу",
+	model.PriorityLow:    "было бы неплохо",
+}
+
+// parseWishlistItem разбирает "Название | ссылка | 1000-2000 | 1", всё кроме названия необязательно и может идти в любом порядке
+func parseWishlistItem(text string) (model.WishlistItem, error) {
+	item := model.WishlistItem{Front: model.TelegramFront, Priority: model.PriorityMedium}
+
+	parts := strings.Split(text, "|")
+	item.Title = strings.TrimSpace(parts[0])
+	if item.Title == "" {
+		return item, errWrongItem
+	}
+
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+			continue
+		case strings.HasPrefix(part, "http://") || strings.HasPrefix(part, "https://"):
+			item.URL = part
+		case len(part) == 1 && part >= "1" && part <= "3":
+			item.Priority = int(part[0] - '0')
+		default:
+			from, to, found := strings.Cut(part, "-")
+			priceFrom, err := strconv.Atoi(strings.TrimSpace(from))
+			if err != nil {
+				return item, errWrongItem
+			}
+			item.PriceFrom = priceFrom
+
+			if found {
+				priceTo, err := strconv.Atoi(strings.TrimSpace(to))
+				if err != nil || priceTo < priceFrom {
+					return item, errWrongItem
+				}
+				item.PriceTo = priceTo
+			}
+		}
+	}
+
+	return item, nil
+}
+
+func formatWishlistItem(i int, item model.WishlistItem) string {
+	line := fmt.Sprintf("%d. %s", i+1, item.Title)
+
+	switch {
+	case item.PriceTo != 0:
+		line += fmt.Sprintf(" (%d-%d ₽)", item.PriceFrom, item.PriceTo)
+	case item.PriceFrom != 0:
+		line += fmt.Sprintf(" (%d ₽)", item.PriceFrom)
+	}
+
+	line += fmt.Sprintf(" [%s]", priorityNames[item.Priority])
+
+	if item.URL != "" {
+		line += "\n" + item.URL
+	}
+
+	if item.ClaimedBy != "" {
+		line += "\n✅ уже покупают"
+	}
+
+	return line
+}
+
+func (b *Bot) getWishlistItems(ownerUID string, viewerUID string) ([]model.WishlistItem, error) {
+	var items []model.WishlistItem
+	code, _, err := b.getJSON(fmt.Sprintf("/wishlist/get/%d/%s?viewer=%s", model.TelegramFront, ownerUID, viewerUID), &items)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("error getting wishlist, status %d", code)
+	}
+
+	return items, nil
+}
+
+// wishAdd добавляет подарок в свой вишлист: /wish_add Название | ссылка | цена | приоритет
+func (b *Bot) wishAdd(_ context.Context, message *messenger.Message) {
+	item, err := parseWishlistItem(message.CommandArguments())
+	if err != nil {
+		msg := messenger.Text(message.From.ID, "Использование: /wish_add Название | ссылка | 1000-2000 | приоритет от 1 (очень хочу) до 3, всё кроме названия необязательно")
+		b.a.Send(msg)
+		return
+	}
+	item.UserUID = fmt.Sprint(message.From.ID)
+
+	code, _, err := b.postJSON("/wishlist/add", item, nil)
+	if err != nil || code != http.StatusOK {
+		log.Err(err).Int("code", code).Msg("error adding wishlist item")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	msg := messenger.Text(message.From.ID, "Добавлено, посмотреть свой вишлист можно командой /wishes")
+	b.a.Send(msg)
+}
+
+// wishes показывает свой вишлист с кнопками удаления
+func (b *Bot) wishes(_ context.Context, message *messenger.Message) {
+	uid := fmt.Sprint(message.From.ID)
+	items, err := b.getWishlistItems(uid, uid)
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist items")
+		msg := messenger.Text(message.From.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	b.a.Send(ownWishlistMessage(message.From.ID, items))
+}
+
+func ownWishlistMessage(chatID int64, items []model.WishlistItem) messenger.Outgoing {
+	if len(items) == 0 {
+		return messenger.Text(chatID, "Вишлист пуст, добавить подарок можно командой /wish_add")
+	}
+
+	lines := []string{"Ваш вишлист:"}
+	rows := messenger.Keyboard{}
+	for i, item := range items {
+		lines = append(lines, formatWishlistItem(i, item))
+		rows = append(rows, []messenger.Button{
+			{Text: fmt.Sprintf("Удалить %d", i+1), Data: fmt.Sprintf("wish:del:%d", item.ID)},
+		})
+	}
+
+	msg := messenger.Text(chatID, strings.Join(lines, "\n"))
+	msg.Keyboard = rows
+	return msg
+}
+
+// gifts показывает в беседе дня рождения вишлист именинника с кнопками "беру"
+func (b *Bot) gifts(ctx context.Context, message *messenger.Message) {
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(message.Chat.ID))
+	if err != nil {
+		msg := messenger.Text(message.Chat.ID, "Эту команду можно использовать только в беседе дня рождения")
+		b.a.Send(msg)
+		return
+	}
+
+	items, err := b.getWishlistItems(birthday.UID, fmt.Sprint(message.From.ID))
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist items")
+		msg := messenger.Text(message.Chat.ID, "Ошибка, попробуйте позже")
+		b.a.Send(msg)
+		return
+	}
+
+	text, keyboard := giftsMessage(birthday.FIO, items)
+	msg := messenger.Text(message.Chat.ID, text)
+	if len(items) > 0 {
+		msg.Keyboard = keyboard
+	}
+	b.a.Send(msg)
+}
+
+func giftsMessage(fio string, items []model.WishlistItem) (string, messenger.Keyboard) {
+	if len(items) == 0 {
+		return fmt.Sprintf("%s пока ничего не добавил(а) в вишлист", fio), nil
+	}
+
+	lines := []string{fmt.Sprintf("Вишлист %s:", fio)}
+	rows := messenger.Keyboard{}
+	for i, item := range items {
+		lines = append(lines, formatWishlistItem(i, item))
+
+		if item.ClaimedBy == "" {
+			rows = append(rows, []messenger.Button{
+				{Text: fmt.Sprintf("Беру %d", i+1), Data: fmt.Sprintf("gift:claim:%d", item.ID)},
+			})
+		} else {
+			rows = append(rows, []messenger.Button{
+				{Text: fmt.Sprintf("Передумал(а) %d", i+1), Data: fmt.Sprintf("gift:unclaim:%d", item.ID)},
+			})
+		}
+	}
+
+	return strings.Join(lines, "\n"), rows
+}
+
+// handleWishlistCallback обрабатывает кнопки удаления своих подарков и "беру" в беседе
+func (b *Bot) handleWishlistCallback(ctx context.Context, query *messenger.Callback) {
+	parts := strings.Split(query.Data, ":")
+	if len(parts) != 3 || query.Message == nil {
+		b.answerCallback(query, "")
+		return
+	}
+
+	itemID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		b.answerCallback(query, "")
+		return
+	}
+
+	uid := fmt.Sprint(query.From.ID)
+	action := model.ItemAction{Front: model.TelegramFront, ItemID: itemID, UID: uid}
+
+	var path string
+	switch parts[0] + ":" + parts[1] {
+	case "wish:del":
+		path = "/wishlist/delete"
+	case "gift:claim":
+		path = "/wishlist/claim"
+	case "gift:unclaim":
+		path = "/wishlist/unclaim"
+	default:
+		b.answerCallback(query, "")
+		return
+	}
+
+	code, response, err := b.postJSON(path, action, nil)
+	if err != nil {
+		log.Err(err).Msg("error sending wishlist item action")
+		b.answerCallback(query, "Ошибка, попробуйте позже")
+		return
+	}
+
+	switch {
+	case code == http.StatusOK:
+		b.answerCallback(query, "Готово")
+	case response.Msg == "item already claimed":
+		b.answerCallback(query, "Этот подарок уже кто-то покупает")
+	case response.Msg == "own item":
+		b.answerCallback(query, "Это ваш собственный вишлист")
+	case parts[1] == "unclaim" && response.Msg == "item not found":
+		b.answerCallback(query, "Этот подарок покупаете не вы")
+	case response.Msg == "user not found":
+		b.answerCallback(query, "Сначала зарегистрируйтесь у меня в личных сообщениях")
+	default:
+		b.answerCallback(query, "Ошибка, попробуйте позже")
+	}
+
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	if parts[0] == "wish" {
+		items, err := b.getWishlistItems(uid, uid)
+		if err != nil {
+			log.Err(err).Msg("error getting wishlist items")
+			return
+		}
+
+		edit := ownWishlistMessage(chatID, items)
+		edit.MessageID = messageID
+		b.a.Edit(edit)
+		return
+	}
+
+	birthday, err := b.s.GetBirthdayByChatID(ctx, fmt.Sprint(chatID))
+	if err != nil {
+		log.Err(err).Msg("error getting birthday by chat")
+		return
+	}
+
+	items, err := b.getWishlistItems(birthday.UID, uid)
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist items")
+		return
+	}
+
+	text, keyboard := giftsMessage(birthday.FIO, items)
+	b.a.Edit(messenger.Outgoing{ChatID: chatID, MessageID: messageID, Text: text, Keyboard: keyboard})
+}
+
+func (b *Bot) answerCallback(query *messenger.Callback, text string) {
+	if err := b.a.AnswerCallback(query.ID, text); err != nil {
+		log.Err(err).Msg("error answering callback")
+	}
+}
@@ -0,0 +1,178 @@
+package dialog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smakimka/balb/internal/bot/messenger"
+)
+
+// Формат callback data календаря:
+// cal:ranges - выбор десятилетия
+// cal:r:<год> - выбор года внутри десятилетия
+// cal:y:<год> - выбор месяца
+// cal:m:<год>:<месяц> - выбор дня
+// cal:d:<год>:<месяц>:<день> - выбранная дата
+// cal:n - пустая кнопка
+const (
+	calendarPrefix = "cal"
+	calendarNoop   = "cal:n"
+
+	yearsInRange = 10
+)
+
+var monthNames = []string{
+	"Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек",
+}
+
+var weekdayNames = []string{"Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"}
+
+func isCalendarData(data string) bool {
+	return strings.HasPrefix(data, calendarPrefix+":")
+}
+
+func rangesKeyboard() messenger.Keyboard {
+	lastYear := time.Now().Year()
+
+	rows := messenger.Keyboard{}
+	row := []messenger.Button{}
+	for start := minBirthYear; start <= lastYear; start += yearsInRange {
+		end := min(start+yearsInRange-1, lastYear)
+		row = append(row, messenger.Button{
+			Text: fmt.Sprintf("%d-%d", start, end),
+			Data: fmt.Sprintf("cal:r:%d", start),
+		})
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = []messenger.Button{}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func yearsKeyboard(start int) messenger.Keyboard {
+	lastYear := time.Now().Year()
+
+	rows := messenger.Keyboard{}
+	row := []messenger.Button{}
+	for year := start; year < start+yearsInRange && year <= lastYear; year++ {
+		row = append(row, messenger.Button{Text: fmt.Sprint(year), Data: fmt.Sprintf("cal:y:%d", year)})
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = []messenger.Button{}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, []messenger.Button{{Text: "« Назад", Data: "cal:ranges"}})
+
+	return rows
+}
+
+func monthsKeyboard(year int) messenger.Keyboard {
+	rows := messenger.Keyboard{}
+	row := []messenger.Button{}
+	for i, name := range monthNames {
+		row = append(row, messenger.Button{Text: name, Data: fmt.Sprintf("cal:m:%d:%d", year, i+1)})
+		if len(row) == 4 {
+			rows = append(rows, row)
+			row = []messenger.Button{}
+		}
+	}
+	rangeStart := year - (year-minBirthYear)%yearsInRange
+	rows = append(rows, []messenger.Button{{Text: "« Назад", Data: fmt.Sprintf("cal:r:%d", rangeStart)}})
+
+	return rows
+}
+
+func daysKeyboard(year int, month time.Month) messenger.Keyboard {
+	rows := messenger.Keyboard{}
+
+	header := []messenger.Button{}
+	for _, name := range weekdayNames {
+		header = append(header, messenger.Button{Text: name, Data: calendarNoop})
+	}
+	rows = append(rows, header)
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	// Неделя начинается с понедельника
+	offset := (int(first.Weekday()) + 6) % 7
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	row := []messenger.Button{}
+	for i := 0; i < offset; i++ {
+		row = append(row, messenger.Button{Text: " ", Data: calendarNoop})
+	}
+	for day := 1; day <= daysInMonth; day++ {
+		row = append(row, messenger.Button{Text: fmt.Sprint(day), Data: fmt.Sprintf("cal:d:%d:%d:%d", year, month, day)})
+		if len(row) == 7 {
+			rows = append(rows, row)
+			row = []messenger.Button{}
+		}
+	}
+	if len(row) > 0 {
+		for len(row) < 7 {
+			row = append(row, messenger.Button{Text: " ", Data: calendarNoop})
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, []messenger.Button{{Text: "« Назад", Data: fmt.Sprintf("cal:y:%d", year)}})
+
+	return rows
+}
+
+// calendarStep разбирает нажатие в календаре, возвращает либо новую клавиатуру с подписью,
+// либо выбранную дату (picked = true)
+func calendarStep(data string) (text string, keyboard messenger.Keyboard, date time.Time, picked bool, err error) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 {
+		return "", keyboard, date, false, ErrWrongDate
+	}
+
+	nums := make([]int, 0, len(parts)-2)
+	for _, part := range parts[2:] {
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return "", keyboard, date, false, ErrWrongDate
+		}
+		nums = append(nums, num)
+	}
+
+	switch {
+	case parts[1] == "ranges":
+		return "Выберите десятилетие", rangesKeyboard(), date, false, nil
+	case parts[1] == "r" && len(nums) == 1:
+		return "Выберите год", yearsKeyboard(nums[0]), date, false, nil
+	case parts[1] == "y" && len(nums) == 1:
+		return fmt.Sprintf("%d год, выберите месяц", nums[0]), monthsKeyboard(nums[0]), date, false, nil
+	case parts[1] == "m" && len(nums) == 2 && nums[1] >= 1 && nums[1] <= 12:
+		return fmt.Sprintf("%s %d, выберите день", monthNames[nums[1]-1], nums[0]), daysKeyboard(nums[0], time.Month(nums[1])), date, false, nil
+	case parts[1] == "d" && len(nums) == 3:
+		// time.Date переносит лишние дни в следующий месяц, такие даты не принимаем
+		date = time.Date(nums[0], time.Month(nums[1]), nums[2], 0, 0, 0, 0, time.UTC)
+		if date.Month() != time.Month(nums[1]) || date.Day() != nums[2] {
+			return "", keyboard, time.Time{}, false, ErrWrongDate
+		}
+		date, err = checkDate(date)
+		if err != nil {
+			return "", keyboard, date, false, err
+		}
+		return "", keyboard, date, true, nil
+	}
+
+	return "", keyboard, date, false, ErrWrongDate
+}
+
+func confirmDateKeyboard() messenger.Keyboard {
+	return messenger.Keyboard{{
+		{Text: "Да", Data: "date:yes"},
+		{Text: "Нет, ввести заново", Data: "date:no"},
+	}}
+}
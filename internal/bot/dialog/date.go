@@ -0,0 +1,85 @@
+package dialog
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrWrongDate = errors.New("wrong date")
+
+const minBirthYear = 1900
+
+// Месяцы сравниваются по префиксу, чтобы подходили и "января", и "янв", и "январь"
+var monthPrefixes = []string{
+	"янв", "фев", "мар", "апр", "ма", "июн", "июл", "авг", "сен", "окт", "ноя", "дек",
+}
+
+var dateLayouts = []string{
+	"2.1.2006",
+	"2006-1-2",
+	"2/1/2006",
+}
+
+// ParseDate понимает 02.01.1990, 2.1.1990, 1990-01-02 и 2 января 1990
+func ParseDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(strings.ToLower(text))
+
+	for _, layout := range dateLayouts {
+		date, err := time.Parse(layout, text)
+		if err == nil {
+			return checkDate(date)
+		}
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 4 && strings.TrimSuffix(fields[3], ".") == "г" {
+		fields = fields[:3]
+	}
+	if len(fields) != 3 {
+		return time.Time{}, ErrWrongDate
+	}
+
+	day, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return time.Time{}, ErrWrongDate
+	}
+
+	month := parseMonth(fields[1])
+	if month == 0 {
+		return time.Time{}, ErrWrongDate
+	}
+
+	year, err := strconv.Atoi(strings.TrimSuffix(fields[2], "г."))
+	if err != nil {
+		return time.Time{}, ErrWrongDate
+	}
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// time.Date нормализует 31 февраля в 3 марта, такое не принимаем
+	if date.Day() != day || date.Month() != month {
+		return time.Time{}, ErrWrongDate
+	}
+
+	return checkDate(date)
+}
+
+func parseMonth(word string) time.Month {
+	// "мар" проверяется раньше "ма", поэтому март не спутается с маем
+	for i, prefix := range monthPrefixes {
+		if strings.HasPrefix(word, prefix) {
+			return time.Month(i + 1)
+		}
+	}
+
+	return 0
+}
+
+func checkDate(date time.Time) (time.Time, error) {
+	if date.Year() < minBirthYear || date.After(time.Now()) {
+		return time.Time{}, ErrWrongDate
+	}
+
+	return date, nil
+}
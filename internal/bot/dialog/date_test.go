@@ -0,0 +1,79 @@
+package dialog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smakimka/balb/internal/bot/dialog"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "old format",
+			text: "02.01.1990",
+			want: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "no leading zeros",
+			text: "2.1.1990",
+			want: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "iso",
+			text: "1990-01-02",
+			want: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "russian month",
+			text: "2 января 1990",
+			want: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "march is not may",
+			text: "8 марта 1985",
+			want: time.Date(1985, 3, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "may with year suffix",
+			text: " 9 Мая 1975 г. ",
+			want: time.Date(1975, 5, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "wrong day",
+			text:    "31 февраля 1990",
+			wantErr: true,
+		},
+		{
+			name:    "future",
+			text:    "01.01.3000",
+			wantErr: true,
+		},
+		{
+			name:    "garbage",
+			text:    "завтра",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date, err := dialog.ParseDate(test.text)
+			if test.wantErr {
+				assert.ErrorIs(t, err, dialog.ErrWrongDate)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.want, date)
+		})
+	}
+}
@@ -2,32 +2,59 @@ package dialog
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/smakimka/balb/internal/bot/messenger"
 	"github.com/smakimka/balb/internal/model"
 	"golang.org/x/net/context"
 )
 
 const (
-	token    = iota
-	fio      = iota
-	birthday = iota
-	wishlist = iota
+	token           = iota
+	fio             = iota
+	birthday        = iota
+	birthdayConfirm = iota
+	wishlist        = iota
+	confirm         = iota
+	// pending анкета заполнена и ждёт одобрения админа
+	pending  = iota
 	finished = iota
 )
 
+// Откуда начато редактирование поля, туда после ввода и возвращаемся
+const (
+	editNone    = iota
+	editSummary = iota
+	editProfile = iota
+)
+
 type UserData struct {
-	status   int
-	FIO      string
-	Birthday time.Time
-	Wishlist string
+	status      int
+	edit        int
+	pendingDate time.Time
+	updatedAt   time.Time
+	// inviteCode код ссылки-приглашения, по которой начата регистрация, использование засчитывается после её завершения
+	inviteCode string
+	FIO        string
+	Birthday   time.Time
+	Wishlist   string
+}
+
+// TokenGuard не даёт подбирать токен: считает неудачные попытки и блокирует ввод
+type TokenGuard interface {
+	// Wait сколько ещё нельзя вводить токен, 0 если можно
+	Wait(ctx context.Context, chatID int64) time.Duration
+	// Failed засчитывает неверный токен и возвращает, на сколько из-за этого заблокирован ввод
+	Failed(ctx context.Context, chatID int64) time.Duration
+	Passed(ctx context.Context, chatID int64)
 }
 
 type Dialog struct {
@@ -35,10 +62,13 @@ type Dialog struct {
 	c         http.Client
 	users     map[int64]UserData
 	authToken string
+	guard     TokenGuard
+	// approval вместо токена регистрацию одобряет админ
+	approval bool
 }
 
-func New(authToken string, c http.Client) *Dialog {
-	return &Dialog{m: sync.RWMutex{}, c: c, users: map[int64]UserData{}, authToken: authToken}
+func New(authToken string, c http.Client, guard TokenGuard, approval bool) *Dialog {
+	return &Dialog{m: sync.RWMutex{}, c: c, users: map[int64]UserData{}, authToken: authToken, guard: guard, approval: approval}
 }
 
 func (d *Dialog) Reset(chatID int64) {
@@ -51,10 +81,80 @@ func (d *Dialog) Reset(chatID int64) {
 	}
 
 	user.status = token
+	user.edit = editNone
+	user.inviteCode = ""
+	d.users[chatID] = user
+}
+
+// StartAuthorized начинает регистрацию сразу с ФИО, когда пользователь пришёл по действующей ссылке-приглашению
+func (d *Dialog) StartAuthorized(chatID int64) messenger.Outgoing {
+	d.m.RLock()
+	userData := d.users[chatID]
+	d.m.RUnlock()
+
+	userData.edit = editNone
+	return d.advance(chatID, userData, fio)
+}
+
+// StartInvited как StartAuthorized, но запоминает код ссылки-приглашения, чтобы засчитать его после регистрации
+func (d *Dialog) StartInvited(chatID int64, code string) messenger.Outgoing {
+	d.m.Lock()
+	userData := d.users[chatID]
+	userData.inviteCode = code
+	d.users[chatID] = userData
+	d.m.Unlock()
+
+	return d.StartAuthorized(chatID)
+}
+
+// TakeInviteCode возвращает код ссылки-приглашения, по которой зарегистрировался пользователь, и забывает его,
+// пустая строка если регистрация не закончена или шла без ссылки
+func (d *Dialog) TakeInviteCode(chatID int64) string {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	user, ok := d.users[chatID]
+	if !ok || user.status != finished {
+		return ""
+	}
+
+	code := user.inviteCode
+	user.inviteCode = ""
 	d.users[chatID] = user
+
+	return code
+}
+
+// ExpireIdle сбрасывает незаконченные регистрации, в которых ничего не происходило дольше timeout,
+// и возвращает chat id пользователей, которым надо об этом напомнить.
+// Брошенное изменение профиля молча отменяется: пользователь уже зарегистрирован, а поля до сохранения не меняются
+func (d *Dialog) ExpireIdle(timeout time.Duration) []int64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	expired := []int64{}
+	for chatID, user := range d.users {
+		if user.status == token || user.status == pending || user.status == finished {
+			continue
+		}
+
+		if time.Since(user.updatedAt) > timeout && user.edit == editProfile {
+			user.status = finished
+			user.edit = editNone
+			d.users[chatID] = user
+			continue
+		}
+
+		if time.Since(user.updatedAt) > timeout {
+			delete(d.users, chatID)
+			expired = append(expired, chatID)
+		}
+	}
+
+	return expired
 }
 
-func (d *Dialog) HandleMessage(ctx context.Context, chatID int64, text string) *tgbotapi.MessageConfig {
+func (d *Dialog) HandleMessage(ctx context.Context, chatID int64, text string) *messenger.Outgoing {
 	d.m.RLock()
 	userData, ok := d.users[chatID]
 	d.m.RUnlock()
@@ -62,7 +162,7 @@ func (d *Dialog) HandleMessage(ctx context.Context, chatID int64, text string) *
 	if !ok {
 		user, err := d.getUser(chatID)
 		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "произошла ошибка, попробуйте позже")
+			msg := messenger.Text(chatID, "произошла ошибка, попробуйте позже")
 			return &msg
 		}
 
@@ -77,58 +177,393 @@ func (d *Dialog) HandleMessage(ctx context.Context, chatID int64, text string) *
 			d.users[chatID] = userData
 		} else {
 			userData = UserData{
-				status: token,
+				status:    token,
+				updatedAt: time.Now(),
+			}
+			// В режиме одобрения токен не нужен, а первое сообщение - не ФИО, поэтому просто просим его ввести
+			if d.approval {
+				userData.status = fio
+				d.users[chatID] = userData
+				d.m.Unlock()
+
+				msg := stepPrompt(chatID, userData)
+				return &msg
 			}
 			d.users[chatID] = userData
 		}
 		d.m.Unlock()
 	}
 
-	var msg tgbotapi.MessageConfig
+	if userData.status == finished {
+		return nil
+	}
+
+	if userData.status == pending {
+		msg := messenger.Text(chatID, "Ваша заявка на регистрацию ещё на рассмотрении у админа, я напишу, когда её рассмотрят")
+		return &msg
+	}
+
+	var msg messenger.Outgoing
+	switch {
+	case text == "/cancel" && userData.edit == editProfile:
+		userData.status = finished
+		userData.edit = editNone
+		d.updateUserData(chatID, userData)
+
+		msg = messenger.Text(chatID, "Изменение отменено")
+		return &msg
+	case text == "/cancel":
+		d.m.Lock()
+		delete(d.users, chatID)
+		d.m.Unlock()
+
+		msg = messenger.Text(chatID, "Регистрация отменена, чтобы начать заново отправьте /start")
+		return &msg
+	case text == "/back":
+		msg = d.back(chatID, userData)
+		return &msg
+	case strings.HasPrefix(text, "/") && userData.status != token:
+		msg = messenger.Text(chatID, "Сначала закончите ввод или отправьте /cancel")
+		return &msg
+	}
+
 	switch userData.status {
 	case token:
-		if text == d.authToken {
-			userData.status = fio
-			d.updateUserData(chatID, userData)
-
-			msg = tgbotapi.NewMessage(chatID, "Введите ваше ФИО")
+		if wait := d.guard.Wait(ctx, chatID); wait > 0 {
+			msg = messenger.Text(chatID, fmt.Sprintf("Слишком много попыток, попробуйте через %s", WaitText(wait)))
+		} else if subtle.ConstantTimeCompare([]byte(text), []byte(d.authToken)) == 1 {
+			d.guard.Passed(ctx, chatID)
+			msg = d.advance(chatID, userData, fio)
+		} else if lock := d.guard.Failed(ctx, chatID); lock > 0 {
+			msg = messenger.Text(chatID, fmt.Sprintf("Неправильно, слишком много попыток, следующая через %s", WaitText(lock)))
 		} else {
-			msg = tgbotapi.NewMessage(chatID, "Неправильно, ещё раз")
+			msg = messenger.Text(chatID, "Неправильно, ещё раз")
 		}
 	case fio:
-		userData.status = birthday
 		userData.FIO = text
-		d.updateUserData(chatID, userData)
-		msg = tgbotapi.NewMessage(chatID, "Введите вашу дату рождения в формате dd.mm.yyyy")
-	case birthday:
-		date, err := time.Parse("02.01.2006", text)
+		msg = d.advance(chatID, userData, birthday)
+	case birthday, birthdayConfirm:
+		date, err := ParseDate(text)
 		if err != nil {
-			msg = tgbotapi.NewMessage(chatID, "неверный формат даты")
+			msg = messenger.Text(chatID, "неверный формат даты, попробуйте например 02.01.1990 или 2 января 1990")
 		} else {
-			userData.status = wishlist
-			userData.Birthday = date
+			userData.status = birthdayConfirm
+			userData.pendingDate = date
 			d.updateUserData(chatID, userData)
 
-			msg = tgbotapi.NewMessage(chatID, "Введите вишлист, пожайлуйста")
+			msg = messenger.Text(chatID, confirmDateText(date))
+			msg.Keyboard = confirmDateKeyboard()
 		}
 	case wishlist:
-		userData.status = finished
 		userData.Wishlist = text
-		d.updateUserData(chatID, userData)
+		msg = d.advance(chatID, userData, confirm)
+	case confirm:
+		msg = stepPrompt(chatID, userData)
+	default:
+		msg = messenger.Text(chatID, "ошибка, не знаю что делать")
+	}
+
+	return &msg
+}
+
+// HandleCallback обрабатывает нажатия на inline кнопки календаря, подтверждения даты и экрана проверки
+func (d *Dialog) HandleCallback(_ context.Context, chatID int64, messageID int, data string) []messenger.Outgoing {
+	d.m.RLock()
+	userData, ok := d.users[chatID]
+	d.m.RUnlock()
+
+	if !ok {
+		return nil
+	}
 
-		err := d.addUser(chatID, userData)
+	if userData.status == confirm {
+		return d.handleConfirmCallback(chatID, messageID, userData, data)
+	}
+
+	if userData.status == finished {
+		return d.handleProfileCallback(chatID, messageID, userData, data)
+	}
+
+	if userData.status != birthday && userData.status != birthdayConfirm {
+		return nil
+	}
+
+	if isCalendarData(data) {
+		if data == calendarNoop {
+			return nil
+		}
+
+		text, keyboard, date, picked, err := calendarStep(data)
 		if err != nil {
-			msg = tgbotapi.NewMessage(chatID, "Что-то пошло не так, попробуйте начать сначала /start")
-		} else {
-			msg = tgbotapi.NewMessage(chatID, "Спасибо за регистрацию, ждите подарков ;)")
+			return nil
+		}
+
+		if !picked {
+			return []messenger.Outgoing{{ChatID: chatID, MessageID: messageID, Text: text, Keyboard: keyboard}}
+		}
+
+		userData.status = birthdayConfirm
+		userData.pendingDate = date
+		d.updateUserData(chatID, userData)
+
+		return []messenger.Outgoing{{ChatID: chatID, MessageID: messageID, Text: confirmDateText(date), Keyboard: confirmDateKeyboard()}}
+	}
+
+	if userData.status != birthdayConfirm {
+		return nil
+	}
+
+	switch data {
+	case "date:yes":
+		userData.Birthday = userData.pendingDate
+
+		return []messenger.Outgoing{
+			messenger.Edit(chatID, messageID, fmt.Sprintf("Дата рождения: %s", userData.Birthday.Format("02.01.2006"))),
+			d.advance(chatID, userData, wishlist),
+		}
+	case "date:no":
+		userData.status = birthday
+		d.updateUserData(chatID, userData)
+
+		return []messenger.Outgoing{{ChatID: chatID, MessageID: messageID, Text: birthdayPromptText, Keyboard: rangesKeyboard()}}
+	}
+
+	return nil
+}
+
+func (d *Dialog) handleConfirmCallback(chatID int64, messageID int, userData UserData, data string) []messenger.Outgoing {
+	var next int
+	switch data {
+	case "reg:confirm":
+		if d.approval {
+			userData.status = pending
+			d.updateUserData(chatID, userData)
+
+			return []messenger.Outgoing{
+				messenger.Edit(chatID, messageID, summaryText(userData)),
+				messenger.Text(chatID, "Заявка отправлена админу, я напишу, когда её рассмотрят"),
+			}
+		}
+
+		if err := d.addUser(chatID, userData); err != nil {
+			return []messenger.Outgoing{messenger.Text(chatID, "Что-то пошло не так, попробуйте нажать ещё раз позже")}
+		}
+
+		userData.status = finished
+		d.updateUserData(chatID, userData)
+
+		return []messenger.Outgoing{
+			messenger.Edit(chatID, messageID, summaryText(userData)),
+			messenger.Text(chatID, "Спасибо за регистрацию, ждите подарков ;)"),
 		}
-	case finished:
+	case "reg:edit:fio":
+		next = fio
+	case "reg:edit:birthday":
+		next = birthday
+	case "reg:edit:wishlist":
+		next = wishlist
+	default:
 		return nil
+	}
+
+	userData.status = next
+	userData.edit = editSummary
+	d.updateUserData(chatID, userData)
+
+	return []messenger.Outgoing{
+		messenger.Edit(chatID, messageID, summaryText(userData)),
+		stepPrompt(chatID, userData),
+	}
+}
+
+// Profile возвращает сообщение с данными зарегистрированного пользователя и кнопками для их изменения
+func (d *Dialog) Profile(chatID int64) messenger.Outgoing {
+	d.m.RLock()
+	userData, ok := d.users[chatID]
+	d.m.RUnlock()
+
+	if !ok || userData.status != finished {
+		return messenger.Text(chatID, "Сначала нужно зарегистрироваться, отправьте /start")
+	}
+
+	return profileMessage(chatID, userData)
+}
+
+func (d *Dialog) handleProfileCallback(chatID int64, messageID int, userData UserData, data string) []messenger.Outgoing {
+	var next int
+	switch data {
+	case "profile:edit:fio":
+		next = fio
+	case "profile:edit:birthday":
+		next = birthday
+	case "profile:edit:wishlist":
+		next = wishlist
 	default:
-		msg = tgbotapi.NewMessage(chatID, "ошибка, не знаю что делать")
+		return nil
 	}
 
-	return &msg
+	userData.status = next
+	userData.edit = editProfile
+	d.updateUserData(chatID, userData)
+
+	return []messenger.Outgoing{
+		messenger.Edit(chatID, messageID, summaryText(userData)),
+		stepPrompt(chatID, userData),
+	}
+}
+
+// advance переводит диалог на следующий шаг, если поле редактировалось с экрана проверки - обратно на него,
+// а если из профиля - сохраняет изменения на сервере
+func (d *Dialog) advance(chatID int64, userData UserData, next int) messenger.Outgoing {
+	switch userData.edit {
+	case editSummary:
+		next = confirm
+	case editProfile:
+		return d.saveProfile(chatID, userData)
+	}
+
+	userData.status = next
+	userData.edit = editNone
+	d.updateUserData(chatID, userData)
+
+	return stepPrompt(chatID, userData)
+}
+
+func (d *Dialog) saveProfile(chatID int64, userData UserData) messenger.Outgoing {
+	d.m.RLock()
+	oldData := d.users[chatID]
+	d.m.RUnlock()
+
+	userData.status = finished
+	userData.edit = editNone
+
+	if err := d.updateUser(chatID, userData); err != nil {
+		oldData.status = finished
+		oldData.edit = editNone
+		d.updateUserData(chatID, oldData)
+
+		return messenger.Text(chatID, "Не получилось сохранить изменения, попробуйте позже")
+	}
+
+	d.updateUserData(chatID, userData)
+
+	msg := profileMessage(chatID, userData)
+	msg.Text = "Данные обновлены\n\n" + msg.Text
+	return msg
+}
+
+func (d *Dialog) back(chatID int64, userData UserData) messenger.Outgoing {
+	var prev int
+	switch {
+	case userData.edit == editProfile:
+		userData.status = finished
+		userData.edit = editNone
+		d.updateUserData(chatID, userData)
+
+		return profileMessage(chatID, userData)
+	case userData.edit == editSummary:
+		prev = confirm
+	case userData.status == birthday || userData.status == birthdayConfirm:
+		prev = fio
+	case userData.status == wishlist:
+		prev = birthday
+	case userData.status == confirm:
+		prev = wishlist
+	default:
+		return messenger.Text(chatID, "Назад некуда, это первый шаг")
+	}
+
+	userData.status = prev
+	userData.edit = editNone
+	d.updateUserData(chatID, userData)
+
+	return stepPrompt(chatID, userData)
+}
+
+func stepPrompt(chatID int64, userData UserData) messenger.Outgoing {
+	switch userData.status {
+	case token:
+		return messenger.Text(chatID, "Для использования этого бота необходимо авторизоваться, введите токен")
+	case fio:
+		return messenger.Text(chatID, "Введите ваше ФИО")
+	case birthday:
+		return birthdayPrompt(chatID)
+	case wishlist:
+		return messenger.Text(chatID, "Введите вишлист, пожайлуйста")
+	case confirm:
+		msg := messenger.Text(chatID, summaryText(userData)+"\n\nВсё верно?")
+		msg.Keyboard = messenger.Keyboard{
+			{{Text: "Всё верно, зарегистрироваться", Data: "reg:confirm"}},
+			{
+				{Text: "Изменить ФИО", Data: "reg:edit:fio"},
+				{Text: "Изменить дату", Data: "reg:edit:birthday"},
+			},
+			{{Text: "Изменить вишлист", Data: "reg:edit:wishlist"}},
+		}
+		return msg
+	}
+
+	return messenger.Text(chatID, "ошибка, не знаю что делать")
+}
+
+func profileMessage(chatID int64, userData UserData) messenger.Outgoing {
+	msg := messenger.Text(chatID, summaryText(userData))
+	msg.Keyboard = messenger.Keyboard{
+		{
+			{Text: "Изменить ФИО", Data: "profile:edit:fio"},
+			{Text: "Изменить дату", Data: "profile:edit:birthday"},
+		},
+		{{Text: "Изменить вишлист", Data: "profile:edit:wishlist"}},
+	}
+	return msg
+}
+
+// WaitText округляет время ожидания вверх до минут
+func WaitText(wait time.Duration) string {
+	minutes := int((wait + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
+func summaryText(userData UserData) string {
+	return fmt.Sprintf("ФИО: %s\nДата рождения: %s\nВишлист:\n%s", userData.FIO, userData.Birthday.Format("02.01.2006"), userData.Wishlist)
+}
+
+// Application возвращает анкету, ожидающую одобрения админа
+func (d *Dialog) Application(chatID int64) (UserData, bool) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	user, ok := d.users[chatID]
+	if !ok || user.status != pending {
+		return UserData{}, false
+	}
+
+	return user, true
+}
+
+// Approve регистрирует пользователя по одобренной админом анкете
+func (d *Dialog) Approve(chatID int64, application UserData) error {
+	if err := d.addUser(chatID, application); err != nil {
+		return err
+	}
+
+	application.status = finished
+	application.edit = editNone
+	d.updateUserData(chatID, application)
+
+	return nil
+}
+
+// Reject забывает отклонённую анкету
+func (d *Dialog) Reject(chatID int64) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	delete(d.users, chatID)
 }
 
 func (d *Dialog) IsRegistered(chatID int64) bool {
@@ -147,10 +582,23 @@ func (d *Dialog) IsRegistered(chatID int64) bool {
 	return true
 }
 
+const birthdayPromptText = "Введите вашу дату рождения (например 02.01.1990 или 2 января 1990) или выберите её в календаре"
+
+func birthdayPrompt(chatID int64) messenger.Outgoing {
+	msg := messenger.Text(chatID, birthdayPromptText)
+	msg.Keyboard = rangesKeyboard()
+	return msg
+}
+
+func confirmDateText(date time.Time) string {
+	return fmt.Sprintf("Ваша дата рождения %s, всё верно?", date.Format("02.01.2006"))
+}
+
 func (d *Dialog) updateUserData(chatID int64, newData UserData) {
 	d.m.Lock()
 	defer d.m.Unlock()
 
+	newData.updatedAt = time.Now()
 	d.users[chatID] = newData
 }
 
@@ -177,6 +625,31 @@ func (d *Dialog) getUser(chatID int64) (*model.User, error) {
 	return nil, nil
 }
 
+func (d *Dialog) updateUser(chatID int64, user UserData) error {
+	data, err := json.Marshal(model.User{
+		Front:    model.TelegramFront,
+		UID:      fmt.Sprint(chatID),
+		FIO:      user.FIO,
+		Birthday: user.Birthday,
+		Wishlist: user.Wishlist,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.c.Post(fmt.Sprintf("http://server:8090/users/update"), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error updating user, status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 func (d *Dialog) addUser(chatID int64, user UserData) error {
 	data, err := json.Marshal(model.User{
 		Front:    model.TelegramFront,
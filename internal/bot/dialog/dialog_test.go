@@ -0,0 +1,268 @@
+package dialog_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smakimka/balb/internal/bot/dialog"
+)
+
+// server на запрос пользователя отвечает, что его нет, а добавление и изменение принимает
+type server struct{}
+
+func (server) RoundTrip(r *http.Request) (*http.Response, error) {
+	code := http.StatusOK
+	if r.Method == http.MethodGet {
+		code = http.StatusNotFound
+	}
+
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"msg":"ok"}`)),
+		Request:    r,
+	}, nil
+}
+
+func TestDialog(t *testing.T) {
+	const summary = "ФИО: Иванов Иван\nДата рождения: 02.01.1990\nВишлист:\nкнига"
+
+	// step сообщение или, если задан callback, нажатие кнопки, want - начало последнего ответа
+	type step struct {
+		text     string
+		callback string
+		want     string
+	}
+	fill := []step{
+		{text: "Иванов Иван", want: "Введите вашу дату рождения"},
+		{text: "02.01.1990", want: "Ваша дата рождения 02.01.1990, всё верно?"},
+		{callback: "date:yes", want: "Введите вишлист"},
+		{text: "книга", want: summary + "\n\nВсё верно?"},
+	}
+
+	tests := []struct {
+		name string
+		// registered начать с зарегистрированного пользователя, а не с ввода ФИО
+		registered bool
+		approval   bool
+		steps      []step
+		// wantRegistered зарегистрирован ли пользователь в конце
+		wantRegistered bool
+	}{
+		{
+			name:           "confirm registers",
+			steps:          append(fill, step{callback: "reg:confirm", want: "Спасибо за регистрацию"}),
+			wantRegistered: true,
+		},
+		{
+			name:     "confirm with approval waits for admin",
+			approval: true,
+			steps: append(fill,
+				step{callback: "reg:confirm", want: "Заявка отправлена админу"},
+				step{text: "привет", want: "Ваша заявка на регистрацию ещё на рассмотрении"},
+			),
+		},
+		{
+			name:  "edit from summary returns to summary",
+			steps: append(fill, step{callback: "reg:edit:fio", want: "Введите ваше ФИО"}, step{text: "Петров Пётр", want: "ФИО: Петров Пётр\n"}),
+		},
+		{
+			name:  "back from summary edit returns to summary",
+			steps: append(fill, step{callback: "reg:edit:wishlist", want: "Введите вишлист"}, step{text: "/back", want: summary}),
+		},
+		{
+			name:  "back from summary",
+			steps: append(fill, step{text: "/back", want: "Введите вишлист"}),
+		},
+		{
+			name: "back to first step",
+			steps: []step{
+				{text: "Иванов Иван", want: "Введите вашу дату рождения"},
+				{text: "/back", want: "Введите ваше ФИО"},
+				{text: "/back", want: "Назад некуда, это первый шаг"},
+			},
+		},
+		{
+			name: "date rejected",
+			steps: []step{
+				{text: "Иванов Иван", want: "Введите вашу дату рождения"},
+				{text: "02.01.1990", want: "Ваша дата рождения 02.01.1990, всё верно?"},
+				{callback: "date:no", want: "Введите вашу дату рождения"},
+			},
+		},
+		{
+			name: "bad date",
+			steps: []step{
+				{text: "Иванов Иван", want: "Введите вашу дату рождения"},
+				{text: "32.13.1990", want: "неверный формат даты"},
+			},
+		},
+		{
+			name: "cancel",
+			steps: []step{
+				{text: "Иванов Иван", want: "Введите вашу дату рождения"},
+				{text: "/cancel", want: "Регистрация отменена"},
+			},
+		},
+		{
+			name:  "other command in the middle",
+			steps: []step{{text: "/list", want: "Сначала закончите ввод или отправьте /cancel"}},
+		},
+		{
+			name:       "profile edit",
+			registered: true,
+			steps: []step{
+				{callback: "profile:edit:wishlist", want: "Введите вишлист"},
+				{text: "книги", want: "Данные обновлены"},
+			},
+			wantRegistered: true,
+		},
+		{
+			name:       "profile edit cancel",
+			registered: true,
+			steps: []step{
+				{callback: "profile:edit:fio", want: "Введите ваше ФИО"},
+				{text: "/cancel", want: "Изменение отменено"},
+			},
+			wantRegistered: true,
+		},
+		{
+			name:       "profile edit back",
+			registered: true,
+			steps: []step{
+				{callback: "profile:edit:fio", want: "Введите ваше ФИО"},
+				{text: "/back", want: summary},
+			},
+			wantRegistered: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			d := dialog.New("token", http.Client{Transport: server{}}, nil, tt.approval)
+			if tt.registered {
+				require.NoError(t, d.Approve(1, dialog.UserData{FIO: "Иванов Иван", Birthday: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC), Wishlist: "книга"}))
+			} else {
+				assert.Equal(t, "Введите ваше ФИО", d.StartAuthorized(1).Text)
+			}
+
+			for i, step := range tt.steps {
+				got := ""
+				if step.callback != "" {
+					if outs := d.HandleCallback(ctx, 1, 7, step.callback); len(outs) > 0 {
+						got = outs[len(outs)-1].Text
+					}
+				} else if msg := d.HandleMessage(ctx, 1, step.text); msg != nil {
+					got = msg.Text
+				}
+				assert.True(t, strings.HasPrefix(got, step.want), "step %d: %q", i, got)
+			}
+			assert.Equal(t, tt.wantRegistered, d.IsRegistered(1))
+		})
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// want начало ответа, пустой - нажатие игнорируется
+		want string
+	}{
+		{name: "ranges start from min birth year", data: "cal:ranges", want: "Выберите десятилетие"},
+		{name: "day picked", data: "cal:d:1990:2:28", want: "Ваша дата рождения 28.02.1990"},
+		{name: "leap day", data: "cal:d:1992:2:29", want: "Ваша дата рождения 29.02.1992"},
+		{name: "day overflows month", data: "cal:d:1990:2:30"},
+		{name: "day 31 in 30 day month", data: "cal:d:1990:4:31"},
+		{name: "month overflows year", data: "cal:d:1990:13:1"},
+		{name: "too old", data: "cal:d:1899:12:31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dialog.New("token", http.Client{Transport: server{}}, nil, false)
+			d.StartAuthorized(1)
+			d.HandleMessage(context.Background(), 1, "Иванов Иван")
+
+			outs := d.HandleCallback(context.Background(), 1, 7, tt.data)
+			if tt.want == "" {
+				assert.Empty(t, outs)
+				return
+			}
+			require.NotEmpty(t, outs)
+			assert.True(t, strings.HasPrefix(outs[len(outs)-1].Text, tt.want), outs[len(outs)-1].Text)
+			if tt.data == "cal:ranges" {
+				assert.Equal(t, "1900-1909", outs[0].Keyboard[0][0].Text)
+			}
+		})
+	}
+}
+
+func TestExpireIdle(t *testing.T) {
+	user := dialog.UserData{FIO: "Иванов Иван", Birthday: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC), Wishlist: "книга"}
+
+	tests := []struct {
+		name  string
+		setup func(d *dialog.Dialog)
+		// want кому напомнить, registered - зарегистрирован ли пользователь после сброса
+		want       []int64
+		registered bool
+	}{
+		{
+			name:  "registration in progress",
+			setup: func(d *dialog.Dialog) { d.StartAuthorized(1) },
+			want:  []int64{1},
+		},
+		{
+			name: "registered user",
+			setup: func(d *dialog.Dialog) {
+				_ = d.Approve(1, user)
+			},
+			want:       []int64{},
+			registered: true,
+		},
+		{
+			name: "profile edit restored",
+			setup: func(d *dialog.Dialog) {
+				_ = d.Approve(1, user)
+				d.HandleCallback(context.Background(), 1, 5, "profile:edit:fio")
+			},
+			want:       []int64{},
+			registered: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dialog.New("token", http.Client{Transport: server{}}, nil, false)
+			tt.setup(d)
+
+			assert.Equal(t, tt.want, d.ExpireIdle(0))
+			assert.Equal(t, tt.registered, d.IsRegistered(1))
+		})
+	}
+}
+
+func TestWaitText(t *testing.T) {
+	tests := []struct {
+		name string
+		wait time.Duration
+		want string
+	}{
+		{name: "rounds seconds up", wait: 10 * time.Second, want: "1 мин"},
+		{name: "whole minutes", wait: 5 * time.Minute, want: "5 мин"},
+		{name: "rounds up to next minute", wait: 5*time.Minute + time.Second, want: "6 мин"},
+		{name: "hour", wait: time.Hour, want: "1 ч 0 мин"},
+		{name: "hours and minutes", wait: 25*time.Hour + 30*time.Minute, want: "25 ч 30 мин"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dialog.WaitText(tt.wait))
+		})
+	}
+}
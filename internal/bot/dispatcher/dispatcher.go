@@ -0,0 +1,90 @@
+// Package dispatcher обрабатывает апдейты бота пулом воркеров так,
+// что апдейты одного чата идут строго по очереди, а разных чатов - параллельно
+package dispatcher
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Dispatcher раскладывает задачи по воркерам по ключу (chat id): у одного ключа всегда один воркер,
+// поэтому порядок сохраняется. Очередь каждого воркера ограничена, при переполнении Dispatch ждёт
+type Dispatcher struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+func New(workers int, queueSize int) *Dispatcher {
+	d := &Dispatcher{queues: make([]chan func(), max(workers, 1))}
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), max(queueSize, 0))
+	}
+
+	return d
+}
+
+// Run запускает воркеров, они работают пока не вызван Stop
+func (d *Dispatcher) Run() {
+	for _, queue := range d.queues {
+		d.wg.Add(1)
+		go d.work(queue)
+	}
+}
+
+// Dispatch ставит задачу в очередь чата key, false если не дождались места до отмены ctx
+func (d *Dispatcher) Dispatch(ctx context.Context, key int64, job func()) bool {
+	queue := d.queues[shard(key, len(d.queues))]
+
+	select {
+	case queue <- job:
+		return true
+	default:
+	}
+
+	log.Warn().Int64("key", key).Msg("dispatcher queue is full, waiting")
+	select {
+	case queue <- job:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Stop перестаёт принимать задачи и ждёт, пока воркеры доделают уже поставленные
+func (d *Dispatcher) Stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work(queue chan func()) {
+	defer d.wg.Done()
+
+	for job := range queue {
+		run(job)
+	}
+}
+
+// run выполняет задачу так, чтобы паника в обработчике не убила воркера
+func run(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Bytes("stack", debug.Stack()).Msg("panic in update handler")
+		}
+	}()
+
+	job()
+}
+
+func shard(key int64, n int) int {
+	// chat id групп отрицательные
+	if key < 0 {
+		key = -key
+	}
+
+	return int(key % int64(n))
+}
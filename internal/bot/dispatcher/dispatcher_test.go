@@ -0,0 +1,77 @@
+package dispatcher_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smakimka/balb/internal/bot/dispatcher"
+)
+
+func TestDispatchKeepsOrderPerKey(t *testing.T) {
+	d := dispatcher.New(4, 8)
+	d.Run()
+
+	m := sync.Mutex{}
+	got := map[int64][]int{}
+	for i := range 100 {
+		for _, key := range []int64{1, 2, -1003} {
+			ok := d.Dispatch(context.Background(), key, func() {
+				m.Lock()
+				defer m.Unlock()
+				got[key] = append(got[key], i)
+			})
+			require.True(t, ok)
+		}
+	}
+	d.Stop()
+
+	for _, key := range []int64{1, 2, -1003} {
+		require.Len(t, got[key], 100)
+		for i, v := range got[key] {
+			assert.Equal(t, i, v)
+		}
+	}
+}
+
+func TestDispatchRecoversFromPanic(t *testing.T) {
+	d := dispatcher.New(1, 1)
+	d.Run()
+
+	done := make(chan struct{})
+	d.Dispatch(context.Background(), 1, func() { panic("boom") })
+	d.Dispatch(context.Background(), 1, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker died after panic")
+	}
+	d.Stop()
+}
+
+func TestDispatchBackpressure(t *testing.T) {
+	d := dispatcher.New(1, 1)
+	d.Run()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Dispatch(context.Background(), 1, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	// Воркер занят, в очереди одно место
+	require.True(t, d.Dispatch(context.Background(), 1, func() {}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.False(t, d.Dispatch(ctx, 1, func() {}))
+
+	close(release)
+	d.Stop()
+}
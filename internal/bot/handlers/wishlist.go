@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/smakimka/balb/internal/bot/storage"
+	"github.com/smakimka/balb/internal/model"
+)
+
+type WishlistHandler struct {
+	s storage.Storage
+}
+
+func NewWishlistHandler(s storage.Storage) WishlistHandler {
+	return WishlistHandler{s: s}
+}
+
+func (h WishlistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.WishlistChange{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	if err := h.s.UpdateWishlist(r.Context(), data); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.Response{})
+}
@@ -0,0 +1,268 @@
+package messenger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Call вызов Fake кроме Send и Edit
+type Call struct {
+	// Method имя метода Messenger
+	Method string
+	ChatID int64
+	// UserID для методов про участников, MessageID - про сообщения
+	UserID    int64
+	MessageID int
+}
+
+// Fake запоминает всё, что через него отправили, для тестов. Ответы Telegram задаются полями
+type Fake struct {
+	m sync.Mutex
+
+	Bot User
+	// Incoming отдаётся из Updates
+	Incoming chan Update
+	// Members ответы ChatMember по {chat id, user id}, если нет - обычный участник
+	Members map[[2]int64]Member
+	// Users ответы User, если нет - пользователь только с ID
+	Users map[int64]User
+	// Poll ответ StopPoll, ThreadID - CreateTopic
+	Poll     Poll
+	ThreadID int
+	// Err если задана, её возвращают все методы, Fail - ошибки отдельных методов кроме Send и Edit по имени
+	Err  error
+	Fail map[string]error
+
+	sent      []Outgoing
+	calls     []Call
+	messageID int
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		Bot:      User{ID: 1, FirstName: "bot", UserName: "balb_bot"},
+		Incoming: make(chan Update, 100),
+		Members:  map[[2]int64]Member{},
+		Users:    map[int64]User{},
+		Fail:     map[string]error{},
+	}
+}
+
+var _ Messenger = (*Fake)(nil)
+
+func (f *Fake) Me() User {
+	return f.Bot
+}
+
+func (f *Fake) Updates(_ context.Context) <-chan Update {
+	return f.Incoming
+}
+
+func (f *Fake) Send(msg Outgoing) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.Err != nil {
+		return 0, f.Err
+	}
+
+	f.messageID++
+	msg.MessageID = f.messageID
+	f.sent = append(f.sent, msg)
+
+	return f.messageID, nil
+}
+
+func (f *Fake) Edit(msg Outgoing) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func (f *Fake) AnswerCallback(_ string, _ string) error {
+	return f.call(Call{Method: "AnswerCallback"})
+}
+
+func (f *Fake) Pin(chatID int64, messageID int) error {
+	return f.call(Call{Method: "Pin", ChatID: chatID, MessageID: messageID})
+}
+
+func (f *Fake) SendPoll(chatID int64, _ string, _ []string) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.Err != nil {
+		return 0, f.Err
+	}
+
+	f.messageID++
+	f.calls = append(f.calls, Call{Method: "SendPoll", ChatID: chatID, MessageID: f.messageID})
+
+	return f.messageID, nil
+}
+
+func (f *Fake) StopPoll(chatID int64, messageID int) (Poll, error) {
+	if err := f.call(Call{Method: "StopPoll", ChatID: chatID, MessageID: messageID}); err != nil {
+		return Poll{}, err
+	}
+
+	return f.Poll, nil
+}
+
+func (f *Fake) User(userID int64) (User, error) {
+	if err := f.call(Call{Method: "User", UserID: userID}); err != nil {
+		return User{}, err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	user, ok := f.Users[userID]
+	if !ok {
+		user = User{ID: userID}
+	}
+
+	return user, nil
+}
+
+func (f *Fake) ChatMember(chatID int64, userID int64) (Member, error) {
+	if err := f.call(Call{Method: "ChatMember", ChatID: chatID, UserID: userID}); err != nil {
+		return Member{}, err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	member, ok := f.Members[[2]int64{chatID, userID}]
+	if !ok {
+		member = Member{User: &User{ID: userID}, Status: "member"}
+	}
+
+	return member, nil
+}
+
+func (f *Fake) ChatLink(chatID int64) (string, error) {
+	if err := f.call(Call{Method: "ChatLink", ChatID: chatID}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://t.me/+chat%d", chatID), nil
+}
+
+func (f *Fake) CreateInviteLink(chatID int64, _ InviteLink) (string, error) {
+	if err := f.call(Call{Method: "CreateInviteLink", ChatID: chatID}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://t.me/+personal%d", chatID), nil
+}
+
+func (f *Fake) RevokeInviteLink(chatID int64, _ string) error {
+	return f.call(Call{Method: "RevokeInviteLink", ChatID: chatID})
+}
+
+func (f *Fake) AnswerJoinRequest(chatID int64, userID int64, approve bool) error {
+	method := "DeclineJoinRequest"
+	if approve {
+		method = "ApproveJoinRequest"
+	}
+
+	return f.call(Call{Method: method, ChatID: chatID, UserID: userID})
+}
+
+func (f *Fake) Unban(chatID int64, userID int64) error {
+	return f.call(Call{Method: "Unban", ChatID: chatID, UserID: userID})
+}
+
+func (f *Fake) Kick(chatID int64, userID int64) error {
+	return f.call(Call{Method: "Kick", ChatID: chatID, UserID: userID})
+}
+
+func (f *Fake) UnpinAll(chatID int64) error {
+	return f.call(Call{Method: "UnpinAll", ChatID: chatID})
+}
+
+func (f *Fake) SetChatTitle(chatID int64, _ string) error {
+	return f.call(Call{Method: "SetChatTitle", ChatID: chatID})
+}
+
+func (f *Fake) SetChatDescription(chatID int64, _ string) error {
+	return f.call(Call{Method: "SetChatDescription", ChatID: chatID})
+}
+
+func (f *Fake) SetChatPhoto(chatID int64, _ string) error {
+	return f.call(Call{Method: "SetChatPhoto", ChatID: chatID})
+}
+
+func (f *Fake) LeaveChat(chatID int64) error {
+	return f.call(Call{Method: "LeaveChat", ChatID: chatID})
+}
+
+func (f *Fake) CreateTopic(chatID int64, _ string) (int, error) {
+	if err := f.call(Call{Method: "CreateTopic", ChatID: chatID}); err != nil {
+		return 0, err
+	}
+
+	return f.ThreadID, nil
+}
+
+func (f *Fake) CloseTopic(chatID int64, threadID int) error {
+	return f.call(Call{Method: "CloseTopic", ChatID: chatID, MessageID: threadID})
+}
+
+func (f *Fake) call(c Call) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	if err := f.Fail[c.Method]; err != nil {
+		return err
+	}
+
+	f.calls = append(f.calls, c)
+	return nil
+}
+
+// Sent всё, что отправили через Send и Edit, по порядку
+func (f *Fake) Sent() []Outgoing {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return append([]Outgoing{}, f.sent...)
+}
+
+// Texts тексты отправленных и отредактированных сообщений в чат chatID
+func (f *Fake) Texts(chatID int64) []string {
+	res := []string{}
+	for _, msg := range f.Sent() {
+		if msg.ChatID == chatID {
+			res = append(res, msg.Text)
+		}
+	}
+
+	return res
+}
+
+// Calls вызовы остальных методов по порядку, если method не пустой - только его
+func (f *Fake) Calls(method string) []Call {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	res := []Call{}
+	for _, c := range f.calls {
+		if method == "" || c.Method == method {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
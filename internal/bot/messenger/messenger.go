@@ -0,0 +1,268 @@
+// Package messenger то, что бот и нотифаер делают в Telegram, за интерфейсом, чтобы их можно было тестировать без сети.
+// Типы пакета не зависят от tgbotapi, с библиотекой работает только Telegram
+package messenger
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Messenger действия бота в мессенджере
+type Messenger interface {
+	// Me сам бот
+	Me() User
+	// Updates апдейты, канал закрывается после отмены ctx
+	Updates(ctx context.Context) <-chan Update
+	// Send отправляет сообщение и возвращает его id
+	Send(msg Outgoing) (int, error)
+	// Edit меняет текст и клавиатуру уже отправленного сообщения msg.MessageID, без Keyboard клавиатура убирается
+	Edit(msg Outgoing) error
+	// AnswerCallback отвечает на нажатие кнопки, text показывается всплывающим уведомлением
+	AnswerCallback(callbackID string, text string) error
+	// Pin закрепляет сообщение без уведомления участников
+	Pin(chatID int64, messageID int) error
+	// SendPoll отправляет анонимный опрос и возвращает id сообщения с ним
+	SendPoll(chatID int64, question string, options []string) (int, error)
+	// StopPoll закрывает опрос и возвращает его итоги
+	StopPoll(chatID int64, messageID int) (Poll, error)
+	// User имя и username пользователя, который писал боту
+	User(userID int64) (User, error)
+	// ChatMember статус пользователя в беседе
+	ChatMember(chatID int64, userID int64) (Member, error)
+	// ChatLink основная ссылка-приглашение в беседу, бот должен быть в ней админом
+	ChatLink(chatID int64) (string, error)
+	// CreateInviteLink создаёт дополнительную ссылку-приглашение с ограничениями из link
+	CreateInviteLink(chatID int64, link InviteLink) (string, error)
+	RevokeInviteLink(chatID int64, link string) error
+	// AnswerJoinRequest принимает или отклоняет заявку на вступление
+	AnswerJoinRequest(chatID int64, userID int64, approve bool) error
+	// Unban без only_if_banned исключает участника из беседы, но не банит его.
+	// В обычных группах (не супергруппах) Telegram так не исключает
+	Unban(chatID int64, userID int64) error
+	// Kick исключает участника из беседы любого типа: банит и сразу разбанивает, чтобы он мог вернуться по новой ссылке
+	Kick(chatID int64, userID int64) error
+	// UnpinAll открепляет все закреплённые сообщения беседы
+	UnpinAll(chatID int64) error
+	SetChatTitle(chatID int64, title string) error
+	SetChatDescription(chatID int64, description string) error
+	// SetChatPhoto ставит фото беседы из файла path
+	SetChatPhoto(chatID int64, path string) error
+	LeaveChat(chatID int64) error
+	// CreateTopic создаёт тему в супергруппе-форуме и возвращает её thread id
+	CreateTopic(chatID int64, name string) (int, error)
+	CloseTopic(chatID int64, threadID int) error
+}
+
+// User пользователь Telegram
+type User struct {
+	ID        int64
+	FirstName string
+	LastName  string
+	UserName  string
+}
+
+// Chat беседа или личный чат с ботом
+type Chat struct {
+	ID int64
+	// Type private, group, supergroup или channel
+	Type  string
+	Title string
+}
+
+func (c Chat) IsPrivate() bool {
+	return c.Type == "private"
+}
+
+func (c Chat) IsGroup() bool {
+	return c.Type == "group"
+}
+
+func (c Chat) IsSuperGroup() bool {
+	return c.Type == "supergroup"
+}
+
+// Button inline кнопка, Data приходит обратно в Callback при нажатии
+type Button struct {
+	Text string
+	Data string
+}
+
+// Keyboard inline кнопки под сообщением по строкам
+type Keyboard [][]Button
+
+// Outgoing сообщение, которое отправляет или редактирует бот
+type Outgoing struct {
+	ChatID int64
+	// MessageID редактируемое сообщение, для Send не нужен
+	MessageID int
+	// ThreadID тема форума, 0 - сообщение в саму беседу
+	ThreadID int
+	Text     string
+	// HTML текст размечен HTML
+	HTML     bool
+	Keyboard Keyboard
+	// ReplyTo сообщение, на которое отвечает это
+	ReplyTo int
+}
+
+// Text простое текстовое сообщение
+func Text(chatID int64, text string) Outgoing {
+	return Outgoing{ChatID: chatID, Text: text}
+}
+
+// HTML сообщение с HTML разметкой
+func HTML(chatID int64, text string) Outgoing {
+	return Outgoing{ChatID: chatID, Text: text, HTML: true}
+}
+
+// Edit новый текст сообщения messageID без клавиатуры
+func Edit(chatID int64, messageID int, text string) Outgoing {
+	return Outgoing{ChatID: chatID, MessageID: messageID, Text: text}
+}
+
+// Update апдейт, заполнено ровно одно поле
+type Update struct {
+	Message  *Message
+	Callback *Callback
+	// ChatMember смена статуса участника беседы, MyChatMember - самого бота
+	ChatMember   *MemberUpdate
+	MyChatMember *MemberUpdate
+	JoinRequest  *JoinRequest
+}
+
+// Message входящее сообщение
+type Message struct {
+	MessageID int
+	From      *User
+	Chat      Chat
+	Text      string
+	// ReplyToMessage сообщение, на которое ответили этим
+	ReplyToMessage *Message
+	// Mentions пользователи, упомянутые без username (text_mention)
+	Mentions []User
+	// MigrateToChatID новый chat id, если группа стала супергруппой
+	MigrateToChatID int64
+}
+
+// IsCommand сообщение начинается с /команды
+func (m *Message) IsCommand() bool {
+	return len(m.Text) > 1 && m.Text[0] == '/'
+}
+
+// Command команда без / и @имени бота
+func (m *Message) Command() string {
+	if !m.IsCommand() {
+		return ""
+	}
+
+	command, _ := m.splitCommand()
+	command, _, _ = strings.Cut(command[1:], "@")
+	return command
+}
+
+// CommandArguments текст после команды
+func (m *Message) CommandArguments() string {
+	if !m.IsCommand() {
+		return ""
+	}
+
+	_, args := m.splitCommand()
+	return args
+}
+
+func (m *Message) splitCommand() (string, string) {
+	i := strings.IndexFunc(m.Text, unicode.IsSpace)
+	if i < 0 {
+		return m.Text, ""
+	}
+
+	return m.Text[:i], m.Text[i+1:]
+}
+
+// Callback нажатие inline кнопки
+type Callback struct {
+	ID   string
+	From User
+	// Message сообщение с кнопкой, Telegram не присылает его для слишком старых сообщений
+	Message *Message
+	Data    string
+}
+
+// Member участник беседы
+type Member struct {
+	User *User
+	// Status creator, administrator, member, restricted, left или kicked
+	Status string
+	// IsMember для restricted: остаётся ли он в беседе
+	IsMember       bool
+	CanInviteUsers bool
+	CanChangeInfo  bool
+	CanPinMessages bool
+}
+
+func (m Member) IsCreator() bool {
+	return m.Status == "creator"
+}
+
+func (m Member) IsAdministrator() bool {
+	return m.Status == "administrator"
+}
+
+func (m Member) HasLeft() bool {
+	return m.Status == "left"
+}
+
+func (m Member) WasKicked() bool {
+	return m.Status == "kicked"
+}
+
+// MemberUpdate смена статуса участника беседы
+type MemberUpdate struct {
+	Chat Chat
+	// From кто поменял статус: сам участник или админ
+	From User
+	Old  Member
+	New  Member
+}
+
+// JoinRequest заявка на вступление в беседу
+type JoinRequest struct {
+	Chat Chat
+	From User
+	// InviteLink ссылка, по которой подана заявка, пустая если заявка подана не по ссылке
+	InviteLink string
+}
+
+// InviteLink ограничения дополнительной ссылки-приглашения
+type InviteLink struct {
+	// Name видно только админам беседы
+	Name string
+	// ExpireDate после этого ссылка не работает, нулевое - бессрочная
+	ExpireDate time.Time
+	// MemberLimit сколько человек могут вступить по ссылке, 0 - без ограничения
+	MemberLimit int
+	// CreatesJoinRequest вместо вступления подаётся заявка, вместе с MemberLimit нельзя
+	CreatesJoinRequest bool
+}
+
+// Poll итоги опроса
+type Poll struct {
+	Options []PollOption
+}
+
+type PollOption struct {
+	Text       string
+	VoterCount int
+}
+
+// Truncate обрезает s до n символов, заменяя хвост многоточием, для полей с ограничением длины в Telegram
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,30 @@
+package messenger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/smakimka/balb/internal/bot/messenger"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{name: "short", s: "книга", n: 10, want: "книга"},
+		{name: "exact", s: "книга", n: 5, want: "книга"},
+		{name: "long", s: "книга про Go", n: 6, want: "книга…"},
+		{name: "counts runes not bytes", s: "ДР Иванов", n: 4, want: "ДР …"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messenger.Truncate(tt.s, tt.n)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len([]rune(got)), tt.n)
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package messenger
+
+import (
+	"context"
+	"encoding/json"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/smakimka/balb/internal/bot/sender"
+)
+
+// Telegram настоящий Telegram: сообщения идут через очередь с лимитами, остальное напрямую
+type Telegram struct {
+	a *tgbotapi.BotAPI
+	q *sender.Queue
+}
+
+func New(a *tgbotapi.BotAPI, q *sender.Queue) *Telegram {
+	return &Telegram{a: a, q: q}
+}
+
+var _ Messenger = (*Telegram)(nil)
+
+func (t *Telegram) Me() User {
+	return user(t.a.Self)
+}
+
+func (t *Telegram) Updates(ctx context.Context) <-chan Update {
+	u := tgbotapi.NewUpdate(0)
+	// Long polling, без таймаута getUpdates отвечает сразу и бот опрашивает API без пауз
+	u.Timeout = 30
+	// chat_member Telegram присылает, только если попросить явно
+	u.AllowedUpdates = []string{"message", "callback_query", "chat_member", "my_chat_member", "chat_join_request"}
+	updates := t.a.GetUpdatesChan(u)
+
+	res := make(chan Update)
+	go func() {
+		defer close(res)
+
+		for {
+			select {
+			case <-ctx.Done():
+				t.a.StopReceivingUpdates()
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+
+				select {
+				case res <- convertUpdate(update):
+				case <-ctx.Done():
+					t.a.StopReceivingUpdates()
+					return
+				}
+			}
+		}
+	}()
+
+	return res
+}
+
+func (t *Telegram) Send(msg Outgoing) (int, error) {
+	// В tgbotapi v5.5.1 нет message_thread_id, поэтому сообщения в тему форума собираются вручную
+	if msg.ThreadID != 0 {
+		params := tgbotapi.Params{}
+		params.AddNonZero64("chat_id", msg.ChatID)
+		params.AddNonZero("message_thread_id", msg.ThreadID)
+		params.AddNonZero("reply_to_message_id", msg.ReplyTo)
+		params["text"] = msg.Text
+		if msg.HTML {
+			params["parse_mode"] = tgbotapi.ModeHTML
+		}
+		if msg.Keyboard != nil {
+			if err := params.AddInterface("reply_markup", keyboard(msg.Keyboard)); err != nil {
+				return 0, err
+			}
+		}
+
+		resp, err := t.q.MakeRequest("sendMessage", params)
+		if err != nil {
+			return 0, err
+		}
+
+		var message tgbotapi.Message
+		err = json.Unmarshal(resp.Result, &message)
+		return message.MessageID, err
+	}
+
+	config := tgbotapi.NewMessage(msg.ChatID, msg.Text)
+	config.ReplyToMessageID = msg.ReplyTo
+	if msg.HTML {
+		config.ParseMode = tgbotapi.ModeHTML
+	}
+	if msg.Keyboard != nil {
+		config.ReplyMarkup = keyboard(msg.Keyboard)
+	}
+
+	sent, err := t.q.Send(config)
+	return sent.MessageID, err
+}
+
+func (t *Telegram) Edit(msg Outgoing) error {
+	config := tgbotapi.NewEditMessageText(msg.ChatID, msg.MessageID, msg.Text)
+	if msg.HTML {
+		config.ParseMode = tgbotapi.ModeHTML
+	}
+	if msg.Keyboard != nil {
+		markup := keyboard(msg.Keyboard)
+		config.ReplyMarkup = &markup
+	}
+
+	_, err := t.q.Send(config)
+	return err
+}
+
+func (t *Telegram) AnswerCallback(callbackID string, text string) error {
+	_, err := t.a.Request(tgbotapi.NewCallback(callbackID, text))
+	return err
+}
+
+func (t *Telegram) Pin(chatID int64, messageID int) error {
+	_, err := t.a.Request(tgbotapi.PinChatMessageConfig{ChatID: chatID, MessageID: messageID, DisableNotification: true})
+	return err
+}
+
+func (t *Telegram) SendPoll(chatID int64, question string, options []string) (int, error) {
+	sent, err := t.q.Send(tgbotapi.NewPoll(chatID, question, options...))
+	return sent.MessageID, err
+}
+
+func (t *Telegram) StopPoll(chatID int64, messageID int) (Poll, error) {
+	poll, err := t.a.StopPoll(tgbotapi.NewStopPoll(chatID, messageID))
+	if err != nil {
+		return Poll{}, err
+	}
+
+	res := Poll{}
+	for _, option := range poll.Options {
+		res.Options = append(res.Options, PollOption{Text: option.Text, VoterCount: option.VoterCount})
+	}
+
+	return res, nil
+}
+
+func (t *Telegram) User(userID int64) (User, error) {
+	chat, err := t.a.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: userID}})
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{ID: chat.ID, FirstName: chat.FirstName, LastName: chat.LastName, UserName: chat.UserName}, nil
+}
+
+func (t *Telegram) ChatMember(chatID int64, userID int64) (Member, error) {
+	member, err := t.a.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		return Member{}, err
+	}
+
+	return convertMember(member), nil
+}
+
+func (t *Telegram) ChatLink(chatID int64) (string, error) {
+	return t.a.GetInviteLink(tgbotapi.ChatInviteLinkConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}})
+}
+
+func (t *Telegram) CreateInviteLink(chatID int64, link InviteLink) (string, error) {
+	config := tgbotapi.CreateChatInviteLinkConfig{
+		ChatConfig:         tgbotapi.ChatConfig{ChatID: chatID},
+		Name:               link.Name,
+		MemberLimit:        link.MemberLimit,
+		CreatesJoinRequest: link.CreatesJoinRequest,
+	}
+	if !link.ExpireDate.IsZero() {
+		config.ExpireDate = int(link.ExpireDate.Unix())
+	}
+
+	resp, err := t.a.Request(config)
+	if err != nil {
+		return "", err
+	}
+
+	var res tgbotapi.ChatInviteLink
+	err = json.Unmarshal(resp.Result, &res)
+	return res.InviteLink, err
+}
+
+func (t *Telegram) RevokeInviteLink(chatID int64, link string) error {
+	_, err := t.a.Request(tgbotapi.RevokeChatInviteLinkConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: chatID}, InviteLink: link})
+	return err
+}
+
+func (t *Telegram) AnswerJoinRequest(chatID int64, userID int64, approve bool) error {
+	chat := tgbotapi.ChatConfig{ChatID: chatID}
+
+	var err error
+	if approve {
+		_, err = t.a.Request(tgbotapi.ApproveChatJoinRequestConfig{ChatConfig: chat, UserID: userID})
+	} else {
+		_, err = t.a.Request(tgbotapi.DeclineChatJoinRequest{ChatConfig: chat, UserID: userID})
+	}
+
+	return err
+}
+
+func (t *Telegram) Unban(chatID int64, userID int64) error {
+	_, err := t.a.Request(tgbotapi.UnbanChatMemberConfig{ChatMemberConfig: tgbotapi.ChatMemberConfig{ChatID: chatID, UserID: userID}})
+	return err
+}
+
+func (t *Telegram) Kick(chatID int64, userID int64) error {
+	member := tgbotapi.ChatMemberConfig{ChatID: chatID, UserID: userID}
+	if _, err := t.a.Request(tgbotapi.BanChatMemberConfig{ChatMemberConfig: member}); err != nil {
+		return err
+	}
+
+	_, err := t.a.Request(tgbotapi.UnbanChatMemberConfig{ChatMemberConfig: member, OnlyIfBanned: true})
+	return err
+}
+
+func (t *Telegram) UnpinAll(chatID int64) error {
+	_, err := t.a.Request(tgbotapi.UnpinAllChatMessagesConfig{ChatID: chatID})
+	return err
+}
+
+func (t *Telegram) SetChatTitle(chatID int64, title string) error {
+	_, err := t.a.Request(tgbotapi.SetChatTitleConfig{ChatID: chatID, Title: title})
+	return err
+}
+
+func (t *Telegram) SetChatDescription(chatID int64, description string) error {
+	_, err := t.a.Request(tgbotapi.SetChatDescriptionConfig{ChatID: chatID, Description: description})
+	return err
+}
+
+func (t *Telegram) SetChatPhoto(chatID int64, path string) error {
+	_, err := t.a.Request(tgbotapi.SetChatPhotoConfig{BaseFile: tgbotapi.BaseFile{
+		BaseChat: tgbotapi.BaseChat{ChatID: chatID},
+		File:     tgbotapi.FilePath(path),
+	}})
+	return err
+}
+
+func (t *Telegram) LeaveChat(chatID int64) error {
+	_, err := t.a.Request(tgbotapi.LeaveChatConfig{ChatID: chatID})
+	return err
+}
+
+// CreateTopic в tgbotapi v5.5.1 нет createForumTopic, поэтому запрос собирается вручную
+func (t *Telegram) CreateTopic(chatID int64, name string) (int, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params["name"] = name
+
+	resp, err := t.q.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	err = json.Unmarshal(resp.Result, &topic)
+	return topic.MessageThreadID, err
+}
+
+func (t *Telegram) CloseTopic(chatID int64, threadID int) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+
+	_, err := t.q.MakeRequest("closeForumTopic", params)
+	return err
+}
+
+func keyboard(k Keyboard) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(k))
+	for _, row := range k {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, button := range row {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+		}
+		rows = append(rows, buttons)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func convertUpdate(u tgbotapi.Update) Update {
+	res := Update{Message: convertMessage(u.Message)}
+
+	if u.CallbackQuery != nil {
+		res.Callback = &Callback{
+			ID:      u.CallbackQuery.ID,
+			Message: convertMessage(u.CallbackQuery.Message),
+			Data:    u.CallbackQuery.Data,
+		}
+		if u.CallbackQuery.From != nil {
+			res.Callback.From = user(*u.CallbackQuery.From)
+		}
+	}
+
+	res.ChatMember = convertMemberUpdate(u.ChatMember)
+	res.MyChatMember = convertMemberUpdate(u.MyChatMember)
+
+	if u.ChatJoinRequest != nil {
+		res.JoinRequest = &JoinRequest{Chat: chat(&u.ChatJoinRequest.Chat), From: user(u.ChatJoinRequest.From)}
+		if u.ChatJoinRequest.InviteLink != nil {
+			res.JoinRequest.InviteLink = u.ChatJoinRequest.InviteLink.InviteLink
+		}
+	}
+
+	return res
+}
+
+func convertMessage(m *tgbotapi.Message) *Message {
+	if m == nil {
+		return nil
+	}
+
+	res := &Message{
+		MessageID:       m.MessageID,
+		Chat:            chat(m.Chat),
+		Text:            m.Text,
+		ReplyToMessage:  convertMessage(m.ReplyToMessage),
+		MigrateToChatID: m.MigrateToChatID,
+	}
+	if m.From != nil {
+		from := user(*m.From)
+		res.From = &from
+	}
+	for _, entity := range m.Entities {
+		if entity.Type == "text_mention" && entity.User != nil {
+			res.Mentions = append(res.Mentions, user(*entity.User))
+		}
+	}
+
+	return res
+}
+
+func convertMemberUpdate(u *tgbotapi.ChatMemberUpdated) *MemberUpdate {
+	if u == nil {
+		return nil
+	}
+
+	return &MemberUpdate{
+		Chat: chat(&u.Chat),
+		From: user(u.From),
+		Old:  convertMember(u.OldChatMember),
+		New:  convertMember(u.NewChatMember),
+	}
+}
+
+func convertMember(m tgbotapi.ChatMember) Member {
+	res := Member{
+		Status:         m.Status,
+		IsMember:       m.IsMember,
+		CanInviteUsers: m.CanInviteUsers,
+		CanChangeInfo:  m.CanChangeInfo,
+		CanPinMessages: m.CanPinMessages,
+	}
+	if m.User != nil {
+		u := user(*m.User)
+		res.User = &u
+	}
+
+	return res
+}
+
+func chat(c *tgbotapi.Chat) Chat {
+	if c == nil {
+		return Chat{}
+	}
+
+	return Chat{ID: c.ID, Type: c.Type, Title: c.Title}
+}
+
+func user(u tgbotapi.User) User {
+	return User{ID: u.ID, FirstName: u.FirstName, LastName: u.LastName, UserName: u.UserName}
+}
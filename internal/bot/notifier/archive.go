@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// archiveBirthdays через ArchiveAfterDays дней после дня рождения закрывает его беседу:
+// отзывает ссылку, прощается, убирает приглашённых и возвращает беседу в пул или выходит из неё
+func (n *Notifier) archiveBirthdays(ctx context.Context) {
+	if n.cfg.ArchiveAfterDays <= 0 {
+		return
+	}
+
+	birthdays, err := n.s.GetActiveBirthdays(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting active birthdays")
+		return
+	}
+
+	now := time.Now()
+	for _, birthday := range birthdays {
+		// Строка создаётся перед днём рождения, так что ближайший после её создания - тот самый
+		archiveAt := birthday.Next(birthday.CreatedAt).AddDate(0, 0, n.cfg.ArchiveAfterDays)
+		if now.Before(archiveAt) {
+			continue
+		}
+
+		n.archiveBirthday(ctx, birthday)
+	}
+}
+
+func (n *Notifier) archiveBirthday(ctx context.Context, birthday storage.BirthdayData) {
+	chatID, err := strconv.ParseInt(birthday.ChatID, 10, 64)
+	if err != nil {
+		log.Err(err).Msg("error convering chat id, should be impossible")
+		return
+	}
+
+	if n.cfg.FarewellMessage != "" {
+		if _, err = n.a.Send(messenger.Outgoing{ChatID: chatID, ThreadID: birthday.ThreadID, Text: n.cfg.FarewellMessage}); err != nil {
+			log.Err(err).Msg("error sending farewell message")
+		}
+	}
+
+	if err = n.a.RevokeInviteLink(chatID, birthday.InviteLink); err != nil {
+		log.Err(err).Msg("error revoking invite link")
+	}
+
+	if birthday.ThreadID != 0 {
+		// Супергруппа общая, поэтому из неё никого не убираем, а только закрываем тему
+		if err = n.a.CloseTopic(chatID, birthday.ThreadID); err != nil {
+			log.Err(err).Msg("error closing forum topic")
+		}
+	} else {
+		n.clearChat(ctx, chatID, birthday)
+	}
+
+	if err = n.s.SetBirthdayArchived(ctx, birthday.ID); err != nil {
+		log.Err(err).Msg("error setting birthday archived")
+	}
+}
+
+// clearChat убирает из беседы приглашённых, открепляет вишлист, опросы и сбор и возвращает беседу в пул,
+// а если беседа была не из пула или убрать всех не получилось - выходит из неё, чтобы следующий день рождения не получил чужих гостей.
+// Список участников Bot API не отдаёт, поэтому убираем тех, кого звали
+func (n *Notifier) clearChat(ctx context.Context, chatID int64, birthday storage.BirthdayData) {
+	cleared := true
+
+	invitees, err := n.s.GetInvitees(ctx, birthday.ID)
+	if err != nil {
+		log.Err(err).Msg("error getting invitees")
+		cleared = false
+	}
+
+	for _, invitee := range invitees {
+		userID, err := strconv.ParseInt(invitee, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		if err = n.a.Kick(chatID, userID); err != nil {
+			log.Err(err).Str("uid", invitee).Msg("error removing member from chat")
+			cleared = false
+		}
+	}
+
+	if err = n.a.UnpinAll(chatID); err != nil {
+		log.Err(err).Msg("error unpinning chat messages")
+		cleared = false
+	}
+
+	if cleared {
+		err = n.s.ReleasePoolChat(ctx, birthday.ChatID)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, storage.ErrPoolChatNotFound) {
+			log.Err(err).Msg("error releasing pool chat")
+			return
+		}
+	} else if err = n.s.RemovePoolChat(ctx, birthday.ChatID); err != nil && !errors.Is(err, storage.ErrPoolChatNotFound) {
+		log.Err(err).Msg("error removing pool chat")
+	}
+
+	if err = n.a.LeaveChat(chatID); err != nil {
+		log.Err(err).Msg("error leaving chat")
+	}
+}
@@ -0,0 +1,113 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// Ограничения Telegram на название темы и ссылки-приглашения
+const (
+	maxTopicNameLen      = 128
+	maxInviteLinkNameLen = 32
+)
+
+// openTopic создаёт для дня рождения тему в супергруппе-форуме вместо отдельной беседы
+func (n *Notifier) openTopic(ctx context.Context, birthday storage.BirthdayData) {
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		log.Err(err).Msg("error generating uuid")
+		return
+	}
+
+	// Код нужен только чтобы день рождения больше не попадал в новые
+	if err = n.s.SetCode(ctx, birthday.ID, uuid.String()); err != nil {
+		log.Err(err).Msg("error setting code")
+		return
+	}
+
+	threadID, link, err := n.createTopic(birthday)
+	if err != nil {
+		log.Err(err).Msg("error creating forum topic")
+		if err = n.s.SetCode(ctx, birthday.ID, ""); err != nil {
+			log.Err(err).Msg("critical, error deleting code after topic couldn't be created")
+		}
+		return
+	}
+
+	n.excludeFromForum(birthday.UID)
+
+	if err = n.s.SetTopic(ctx, birthday.ID, fmt.Sprint(n.cfg.ForumChatID), threadID, link); err != nil {
+		log.Err(err).Msg("error saving forum topic")
+		return
+	}
+
+	messageID, err := n.a.Send(messenger.Outgoing{ChatID: n.cfg.ForumChatID, ThreadID: threadID, Text: birthday.WishlistText()})
+	if err != nil {
+		log.Err(err).Msg("error sending wishlist to forum topic")
+		return
+	}
+
+	if err = n.a.Pin(n.cfg.ForumChatID, messageID); err != nil {
+		log.Err(err).Msg("error pinning wishlist")
+	}
+
+	if err = n.s.SetWishlistMessageID(ctx, birthday.ID, messageID); err != nil {
+		log.Err(err).Msg("error saving wishlist message id")
+	}
+}
+
+// createTopic создаёт тему и ссылку-приглашение в супергруппу для неё
+func (n *Notifier) createTopic(birthday storage.BirthdayData) (int, string, error) {
+	name := fmt.Sprintf("%s %s", birthday.FIO, birthday.Date.Format("02.01"))
+
+	threadID, err := n.a.CreateTopic(n.cfg.ForumChatID, messenger.Truncate(name, maxTopicNameLen))
+	if err != nil {
+		return 0, "", err
+	}
+
+	link, err := n.a.CreateInviteLink(n.cfg.ForumChatID, messenger.InviteLink{Name: messenger.Truncate(name, maxInviteLinkNameLen)})
+	if err != nil {
+		return 0, "", err
+	}
+
+	return threadID, link, nil
+}
+
+// excludeFromForum убирает именинника из супергруппы, скрыть от участника отдельную тему Telegram не позволяет.
+// Без only_if_banned unbanChatMember исключает участника, но оставляет ему возможность вернуться позже
+func (n *Notifier) excludeFromForum(uid string) {
+	userID, err := strconv.ParseInt(uid, 10, 64)
+	if err != nil {
+		log.Err(err).Msg("error convering chat id, should be impossible")
+		return
+	}
+
+	member, err := n.a.ChatMember(n.cfg.ForumChatID, userID)
+	if err != nil {
+		log.Err(err).Msg("error getting birthday person forum membership")
+		return
+	}
+	if member.HasLeft() || member.WasKicked() {
+		return
+	}
+	if member.IsAdministrator() || member.IsCreator() {
+		log.Warn().Str("uid", uid).Msg("birthday person is forum admin and can see the topic")
+		return
+	}
+
+	if err = n.a.Unban(n.cfg.ForumChatID, userID); err != nil {
+		log.Err(err).Msg("error removing birthday person from forum")
+	}
+}
+
+// topicLink ссылка на тему, для супергрупп chat id в ссылке пишется без префикса -100
+func topicLink(chatID string, threadID int) string {
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(chatID, "-100"), threadID)
+}
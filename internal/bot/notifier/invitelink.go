@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// personalLink создаёт приглашённому личную ссылку, которая перестаёт работать после дня рождения:
+// одноразовую или, в режиме заявок, с заявкой на вступление. Общую ссылку беседы вместо неё не отдаём, её можно переслать кому угодно
+func (n *Notifier) personalLink(ctx context.Context, invite storage.InviteData) (string, error) {
+	if invite.PersonalLink != "" {
+		return invite.PersonalLink, nil
+	}
+
+	chatID, err := strconv.ParseInt(invite.GroupID, 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	config := messenger.InviteLink{
+		Name:       messenger.Truncate(invite.ChatID, maxInviteLinkNameLen),
+		ExpireDate: storage.BirthdayData{Date: invite.Date}.Next(time.Now()).AddDate(0, 0, 1),
+	}
+	// Telegram не даёт ограничить число вступлений у ссылки с заявками
+	if n.cfg.JoinRequests {
+		config.CreatesJoinRequest = true
+	} else {
+		config.MemberLimit = 1
+	}
+
+	link, err := n.a.CreateInviteLink(chatID, config)
+	if err != nil {
+		return "", err
+	}
+
+	// Несохранённую ссылку бот не узнает в заявке на вступление, поэтому её не отправляем
+	stored, err := n.s.SetInviteLink(ctx, invite.ID, link)
+	if err != nil {
+		return "", err
+	}
+
+	// Ссылку уже успели сохранить раньше, лишнюю отзываем и отдаём сохранённую
+	if stored != link {
+		if err = n.a.RevokeInviteLink(chatID, link); err != nil {
+			log.Err(err).Msg("error revoking extra personal invite link")
+		}
+	}
+
+	return stored, nil
+}
@@ -4,22 +4,56 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
 	"github.com/smakimka/balb/internal/bot/storage"
 )
 
+type Config struct {
+	AdminChatID          int
+	CollectionRemindDays int
+	// ForumChatID супергруппа с темами, если задана - вместо отдельных бесед для дней рождения создаются темы в ней
+	ForumChatID int64
+	// PoolChatPhoto путь к фото для бесед из пула, PoolLowThreshold - при скольких свободных беседах предупреждать админа
+	PoolChatPhoto    string
+	PoolLowThreshold int
+	// ArchiveAfterDays через сколько дней после дня рождения закрывать беседу (0 - не закрывать),
+	// FarewellMessage - что написать в беседе перед этим (пусто - ничего)
+	ArchiveAfterDays int
+	FarewellMessage  string
+	// JoinRequests личные ссылки создают заявки на вступление, которые бот одобряет сам, вместо одноразовых ссылок
+	JoinRequests bool
+	// JoinRemindAfter через сколько после приглашения напомнить тем, кто так и не зашёл (0 - не напоминать)
+	JoinRemindAfter time.Duration
+	// Запросы создать беседу распределяются между админами по очереди,
+	// запрос без ответа повторяется следующему админу через RequestRemindAfter, код привязки действует CodeTTL
+	RequestRemindAfter time.Duration
+	CodeTTL            time.Duration
+}
+
+// Binder то, что делает бот в беседе сразу после её привязки к дню рождения
+type Binder interface {
+	Bind(ctx context.Context, chatID int64, birthday storage.BirthdayData)
+}
+
 type Notifier struct {
-	s           storage.Storage
-	a           *tgbotapi.BotAPI
-	adminChatID int
+	s   storage.Storage
+	a   messenger.Messenger
+	b   Binder
+	cfg Config
+	// nextAdmin счётчик для распределения запросов между админами по кругу
+	nextAdmin atomic.Uint64
+	// running флаги задач по имени: пока задача не закончилась, новые тики её не запускают
+	running sync.Map
 }
 
-func New(s storage.Storage, a *tgbotapi.BotAPI, adminChatID int) *Notifier {
-	return &Notifier{s: s, a: a, adminChatID: adminChatID}
+func New(s storage.Storage, a messenger.Messenger, b Binder, cfg Config) *Notifier {
+	return &Notifier{s: s, a: a, b: b, cfg: cfg}
 }
 
 // Значения тикеров лучше брать из конфига, но норм
@@ -27,19 +61,55 @@ func (n *Notifier) Run(ctx context.Context) {
 	log.Info().Msg("started notifier goroutine")
 	askTiker := time.NewTicker(10 * time.Second)
 	inviteTicker := time.NewTicker(10 * time.Second)
+	wishlistTicker := time.NewTicker(10 * time.Second)
+	collectionTicker := time.NewTicker(time.Minute)
+	pollTicker := time.NewTicker(time.Minute)
+	archiveTicker := time.NewTicker(time.Hour)
+	joinTicker := time.NewTicker(time.Minute)
+	requestTicker := time.NewTicker(time.Minute)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-askTiker.C:
-			go n.askForChats(ctx)
+			n.start(ctx, "ask for chats", n.askForChats)
 		case <-inviteTicker.C:
-			go n.inviteGuests(ctx)
+			n.start(ctx, "invite guests", n.inviteGuests)
+		case <-wishlistTicker.C:
+			n.start(ctx, "update wishlists", n.updateWishlists)
+		case <-collectionTicker.C:
+			n.start(ctx, "remind collections", n.remindCollections)
+		case <-pollTicker.C:
+			n.start(ctx, "close polls", n.closePolls)
+		case <-archiveTicker.C:
+			n.start(ctx, "archive birthdays", n.archiveBirthdays)
+		case <-joinTicker.C:
+			n.start(ctx, "remind not joined", n.remindNotJoined)
+		case <-requestTicker.C:
+			n.start(ctx, "remind admins", n.remindAdmins)
 		}
 	}
 }
 
+// start запускает задачу в отдельной горутине, если прошлый запуск ещё идёт - тик пропускается.
+// Отправка ждёт в очереди и может занять дольше тика, а два запуска разошлют одно и то же дважды
+func (n *Notifier) start(ctx context.Context, name string, job func(ctx context.Context)) bool {
+	flag, _ := n.running.LoadOrStore(name, &atomic.Bool{})
+	running := flag.(*atomic.Bool)
+	if !running.CompareAndSwap(false, true) {
+		log.Debug().Str("job", name).Msg("previous run is still going, skipping tick")
+		return false
+	}
+
+	go func() {
+		defer running.Store(false)
+		job(ctx)
+	}()
+
+	return true
+}
+
 func (n *Notifier) inviteGuests(ctx context.Context) {
 	invites, err := n.s.GetNotSentInvites(ctx)
 	if err != nil {
@@ -48,27 +118,29 @@ func (n *Notifier) inviteGuests(ctx context.Context) {
 	}
 
 	for _, invite := range invites {
-		start := time.Now()
-
 		chatID, err := strconv.Atoi(invite.ChatID)
 		if err != nil {
 			log.Err(err).Msg("error convering chat id, should be impossible")
 			continue
 		}
 
-		msg := tgbotapi.NewMessage(
-			int64(chatID),
-			fmt.Sprintf("Скоро (%s) у %s день рождения, вы подписаны, поэтому заходите %s", invite.Date.Format("02.01"), invite.FIO, invite.Link),
-		)
-		_, err = n.a.Send(msg)
+		// Без личной ссылки приглашение остаётся неотправленным и повторяется на следующем тике
+		link, err := n.personalLink(ctx, invite)
 		if err != nil {
-			log.Err(err).Msg("error sending invite")
+			log.Err(err).Msg("error creating personal invite link")
 			continue
 		}
 
-		elapsed := time.Since(start)
-		if elapsed < time.Second {
-			time.Sleep(time.Second - elapsed)
+		text := fmt.Sprintf("Скоро (%s) у %s день рождения, вы подписаны, поэтому заходите %s", invite.Date.Format("02.01"), invite.FIO, link)
+		if invite.ThreadID != 0 {
+			text += fmt.Sprintf("\nОбсуждение в теме %s", topicLink(invite.GroupID, invite.ThreadID))
+		}
+
+		msg := messenger.Text(int64(chatID), text)
+		_, err = n.a.Send(msg)
+		if err != nil {
+			log.Err(err).Msg("error sending invite")
+			continue
 		}
 
 		if err = n.s.UpdateInviteStatus(ctx, invite.ID, storage.InviteDone); err != nil {
@@ -77,41 +149,231 @@ func (n *Notifier) inviteGuests(ctx context.Context) {
 	}
 }
 
-func (n *Notifier) askForChats(ctx context.Context) {
-	birthdays, err := n.s.GetNewBirthdays(ctx)
+// remindNotJoined напоминает о беседе приглашённым, которые так в неё и не зашли
+func (n *Notifier) remindNotJoined(ctx context.Context) {
+	if n.cfg.JoinRemindAfter <= 0 {
+		return
+	}
+
+	invites, err := n.s.GetNotJoinedInvites(ctx, n.cfg.JoinRemindAfter)
 	if err != nil {
-		log.Err(err).Msg("error getting birthdays")
+		log.Err(err).Msg("error getting not joined invites")
 		return
 	}
 
-	for _, birthday := range birthdays {
-		uuid, err := uuid.NewRandom()
+	for _, invite := range invites {
+		chatID, err := strconv.ParseInt(invite.ChatID, 10, 64)
 		if err != nil {
-			log.Err(err).Msg("error generating uuid")
+			log.Err(err).Msg("error convering chat id, should be impossible")
 			continue
 		}
-		code := uuid.String()
 
-		if err = n.s.SetCode(ctx, birthday.ID, code); err != nil {
-			log.Err(err).Msg("error setting code")
+		link := invite.PersonalLink
+		if link == "" {
+			link = invite.Link
+		}
+
+		msg := messenger.Text(
+			chatID,
+			fmt.Sprintf("Напоминаю, скоро (%s) день рождения у %s, а вы ещё не зашли в беседу: %s", invite.Date.Format("02.01"), invite.FIO, link),
+		)
+		if _, err = n.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending join reminder")
 			continue
 		}
 
-		msg := tgbotapi.NewMessage(
-			int64(n.adminChatID),
-			fmt.Sprintf("Скоро (%s) день рождения у %s, пожадуйста создайте чат, дайте мне там админа и введите в нём команду '/birthday %s'",
-				birthday.Date.Format("02.01"), birthday.FIO, code),
+		if err = n.s.SetInviteReminded(ctx, invite.ID); err != nil {
+			log.Err(err).Msg("error setting invite reminded")
+		}
+	}
+}
+
+// updateWishlists рассылает подписчикам изменения вишлистов и обновляет закреплённый вишлист в беседах
+func (n *Notifier) updateWishlists(ctx context.Context) {
+	updates, err := n.s.GetNotSentWishlistUpdates(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist updates")
+		return
+	}
+
+	for _, update := range updates {
+		chatID, err := strconv.Atoi(update.ChatID)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		msg := messenger.Text(
+			int64(chatID),
+			fmt.Sprintf("%s обновил(а) вишлист:\n%s", update.FIO, update.Wishlist),
 		)
+		if _, err = n.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending wishlist update")
+			continue
+		}
 
-		_, err = n.a.Send(msg)
-		if err == nil {
-			return
-		} else {
-			log.Err(err).Msg("error sending create chat request, this is bad")
+		if err = n.s.SetWishlistUpdateSent(ctx, update.ID); err != nil {
+			log.Err(err).Msg("error remembering sent wishlist update")
+		}
+	}
+
+	birthdays, err := n.s.GetChangedWishlists(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting changed wishlists")
+		return
+	}
+
+	for _, birthday := range birthdays {
+		chatID, err := strconv.ParseInt(birthday.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		messageID := birthday.WishlistMessageID
+		if messageID != 0 {
+			err = n.a.Edit(messenger.Edit(chatID, messageID, birthday.WishlistText()))
+		}
+		if messageID == 0 || err != nil {
+			messageID, err = n.a.Send(messenger.Outgoing{ChatID: chatID, ThreadID: birthday.ThreadID, Text: birthday.WishlistText()})
+			if err != nil {
+				log.Err(err).Msg("error sending updated wishlist")
+				continue
+			}
+
+			if err = n.a.Pin(chatID, messageID); err != nil {
+				log.Err(err).Msg("error pinning wishlist")
+			}
 		}
 
-		if err = n.s.SetCode(ctx, birthday.ID, ""); err != nil {
-			log.Err(err).Msg("critical, cerror deleting code after msg to admin couldn't be sent")
+		if err = n.s.SetWishlistMessageID(ctx, birthday.ID, messageID); err != nil {
+			log.Err(err).Msg("error saving wishlist message id")
 		}
 	}
 }
+
+// remindCollections за collectionRemindDays дней до дня рождения напоминает о сборе тем, кто ещё не скинулся
+func (n *Notifier) remindCollections(ctx context.Context) {
+	if n.cfg.CollectionRemindDays <= 0 {
+		return
+	}
+
+	collections, err := n.s.GetNotRemindedCollections(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting collections")
+		return
+	}
+
+	now := time.Now()
+	for _, collection := range collections {
+		next := storage.BirthdayData{Date: collection.Date}.Next(now)
+		daysLeft := int(next.Sub(now).Hours() / 24)
+		if daysLeft > n.cfg.CollectionRemindDays {
+			continue
+		}
+
+		for _, invitee := range collection.NotContributed {
+			chatID, err := strconv.ParseInt(invitee, 10, 64)
+			if err != nil {
+				log.Err(err).Msg("error convering chat id, should be impossible")
+				continue
+			}
+
+			msg := messenger.Text(
+				chatID,
+				fmt.Sprintf("Скоро (%s) день рождения у %s, идёт сбор на подарок (реквизиты: %s), если участвуете - отметьтесь в беседе командой /chipin <сумма>",
+					next.Format("02.01"), collection.FIO, collection.Details),
+			)
+			if _, err = n.a.Send(msg); err != nil {
+				log.Err(err).Msg("error sending collection reminder")
+			}
+		}
+
+		if err = n.s.SetCollectionReminded(ctx, collection.BirthdayID); err != nil {
+			log.Err(err).Msg("error setting collection reminded")
+		}
+	}
+}
+
+// closePolls закрывает опросы о подарке, срок которых вышел, и объявляет победителя
+func (n *Notifier) closePolls(ctx context.Context) {
+	polls, err := n.s.GetDuePolls(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting due polls")
+		return
+	}
+
+	for _, poll := range polls {
+		chatID, err := strconv.ParseInt(poll.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+
+		result, err := n.a.StopPoll(chatID, poll.MessageID)
+		if err != nil {
+			log.Err(err).Msg("error stopping poll")
+			// Опрос мог быть удалён или уже закрыт вручную, второй раз пробовать бессмысленно
+			if err = n.s.SetPollClosed(ctx, poll.ID); err != nil {
+				log.Err(err).Msg("error setting poll closed")
+			}
+			continue
+		}
+
+		if err = n.s.SetPollClosed(ctx, poll.ID); err != nil {
+			log.Err(err).Msg("error setting poll closed")
+		}
+
+		text := "Опрос закрыт, но никто не проголосовал"
+		if winners := pollWinners(result); len(winners) > 0 {
+			text = fmt.Sprintf("Опрос закрыт, побеждает: %s", strings.Join(winners, ", "))
+		}
+
+		msg := messenger.Text(chatID, text)
+		msg.ReplyTo = poll.MessageID
+		if _, err = n.a.Send(msg); err != nil {
+			log.Err(err).Msg("error announcing poll winner")
+		}
+	}
+}
+
+func pollWinners(poll messenger.Poll) []string {
+	maxVotes := 0
+	for _, option := range poll.Options {
+		maxVotes = max(maxVotes, option.VoterCount)
+	}
+
+	winners := []string{}
+	if maxVotes == 0 {
+		return winners
+	}
+
+	for _, option := range poll.Options {
+		if option.VoterCount == maxVotes {
+			winners = append(winners, option.Text)
+		}
+	}
+
+	return winners
+}
+
+func (n *Notifier) askForChats(ctx context.Context) {
+	birthdays, err := n.s.GetNewBirthdays(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting birthdays")
+		return
+	}
+
+	for _, birthday := range birthdays {
+		if n.cfg.ForumChatID != 0 {
+			n.openTopic(ctx, birthday)
+			continue
+		}
+
+		if n.assignPoolChat(ctx, birthday) {
+			continue
+		}
+
+		n.requestChat(ctx, birthday)
+	}
+}
@@ -0,0 +1,409 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+	mock_storage "github.com/smakimka/balb/internal/bot/storage/mock"
+)
+
+// binder запоминает, какие беседы привязали
+type binder struct {
+	chats []int64
+}
+
+func (b *binder) Bind(_ context.Context, chatID int64, _ storage.BirthdayData) {
+	b.chats = append(b.chats, chatID)
+}
+
+func TestAskForChats(t *testing.T) {
+	birthday := storage.BirthdayData{
+		ID:   1,
+		UID:  "300",
+		Date: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		FIO:  "Иванов Иван",
+	}
+
+	type want struct {
+		// texts сообщения по чатам
+		texts map[int64][]string
+		bound []int64
+		// calls методы Messenger кроме Send и Edit
+		calls []string
+	}
+	tests := []struct {
+		name  string
+		cfg   Config
+		setup func(s *mock_storage.MockStorage, f *messenger.Fake)
+		want  want
+	}{
+		{
+			name: "request chat from admin",
+			cfg:  Config{AdminChatID: 100},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+				s.EXPECT().TakePoolChat(gomock.Any(), 1).Return("", storage.ErrPoolEmpty)
+				s.EXPECT().SetCode(gomock.Any(), 1, gomock.Any()).Return(nil)
+				s.EXPECT().GetAdmins(gomock.Any()).Return([]storage.AdminData{{ChatID: "100", Role: storage.AdminRoleOwner}}, nil)
+				s.EXPECT().SetRequested(gomock.Any(), 1, "100", time.Time{}).Return(nil)
+			},
+			want: want{texts: map[int64][]string{100: {"Скоро (02.01) день рождения у Иванов Иван, пожадуйста создайте чат"}}},
+		},
+		{
+			name: "first request goes to first admin",
+			cfg:  Config{AdminChatID: 100},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+				s.EXPECT().TakePoolChat(gomock.Any(), 1).Return("", storage.ErrPoolEmpty)
+				s.EXPECT().SetCode(gomock.Any(), 1, gomock.Any()).Return(nil)
+				s.EXPECT().GetAdmins(gomock.Any()).Return([]storage.AdminData{{ChatID: "100", Role: storage.AdminRoleOwner}, {ChatID: "101"}}, nil)
+				s.EXPECT().SetRequested(gomock.Any(), 1, "100", time.Time{}).Return(nil)
+			},
+			want: want{texts: map[int64][]string{100: {"Скоро (02.01) день рождения у Иванов Иван, пожадуйста создайте чат"}, 101: {}}},
+		},
+		{
+			name: "take chat from pool",
+			cfg:  Config{AdminChatID: 100, PoolLowThreshold: 2},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+				s.EXPECT().TakePoolChat(gomock.Any(), 1).Return("-200", nil)
+				s.EXPECT().SetCode(gomock.Any(), 1, gomock.Any()).Return(nil)
+				s.EXPECT().UpdateLinkAndChatIDByCode(gomock.Any(), gomock.Any(), "-200", "https://t.me/+chat-200").Return(nil)
+				s.EXPECT().CountFreePoolChats(gomock.Any()).Return(1, nil)
+				s.EXPECT().GetAdmins(gomock.Any()).Return([]storage.AdminData{{ChatID: "100", Role: storage.AdminRoleOwner}, {ChatID: "101"}}, nil)
+			},
+			want: want{
+				texts: map[int64][]string{100: {"В пуле осталось свободных бесед: 1"}, 101: {"В пуле осталось свободных бесед: 1"}},
+				bound: []int64{-200},
+				calls: []string{"ChatLink", "SetChatTitle", "SetChatDescription"},
+			},
+		},
+		{
+			name: "pool chat released on bind error",
+			cfg:  Config{AdminChatID: 100},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+				s.EXPECT().TakePoolChat(gomock.Any(), 1).Return("-200", nil)
+				s.EXPECT().SetCode(gomock.Any(), 1, gomock.Any()).Return(nil).Times(2)
+				s.EXPECT().UpdateLinkAndChatIDByCode(gomock.Any(), gomock.Any(), "-200", "https://t.me/+chat-200").Return(errors.New("db is down"))
+				s.EXPECT().ReleasePoolChat(gomock.Any(), "-200").Return(nil)
+				s.EXPECT().GetAdmins(gomock.Any()).Return([]storage.AdminData{{ChatID: "100", Role: storage.AdminRoleOwner}}, nil)
+				s.EXPECT().SetRequested(gomock.Any(), 1, "100", time.Time{}).Return(nil)
+			},
+			want: want{
+				texts: map[int64][]string{100: {"Скоро (02.01) день рождения у Иванов Иван, пожадуйста создайте чат"}},
+				calls: []string{"ChatLink"},
+			},
+		},
+		{
+			name: "open forum topic",
+			cfg:  Config{AdminChatID: 100, ForumChatID: -1000},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				f.ThreadID = 7
+
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return([]storage.BirthdayData{birthday}, nil)
+				s.EXPECT().SetCode(gomock.Any(), 1, gomock.Any()).Return(nil)
+				s.EXPECT().SetTopic(gomock.Any(), 1, "-1000", 7, "https://t.me/+personal-1000").Return(nil)
+				s.EXPECT().SetWishlistMessageID(gomock.Any(), 1, 1).Return(nil)
+			},
+			want: want{
+				texts: map[int64][]string{-1000: {birthday.WishlistText()}},
+				calls: []string{"CreateTopic", "CreateInviteLink", "ChatMember", "Unban", "Pin"},
+			},
+		},
+		{
+			name: "storage error",
+			cfg:  Config{AdminChatID: 100},
+			setup: func(s *mock_storage.MockStorage, f *messenger.Fake) {
+				s.EXPECT().GetNewBirthdays(gomock.Any()).Return(nil, errors.New("db is down"))
+			},
+			want: want{texts: map[int64][]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			f := messenger.NewFake()
+			b := &binder{}
+			tt.setup(s, f)
+
+			New(s, f, b, tt.cfg).askForChats(context.Background())
+
+			for chatID, texts := range tt.want.texts {
+				got := f.Texts(chatID)
+				require.Len(t, got, len(texts))
+				for i, text := range texts {
+					assert.True(t, strings.HasPrefix(got[i], text), got[i])
+				}
+			}
+			if len(tt.want.texts) == 0 {
+				assert.Empty(t, f.Sent())
+			}
+			assert.Equal(t, tt.want.bound, b.chats)
+
+			calls := []string{}
+			for _, c := range f.Calls("") {
+				calls = append(calls, c.Method)
+			}
+			if tt.want.calls == nil {
+				tt.want.calls = []string{}
+			}
+			assert.Equal(t, tt.want.calls, calls)
+		})
+	}
+}
+
+func TestInviteGuests(t *testing.T) {
+	invite := storage.InviteData{
+		ID:      5,
+		Date:    time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		FIO:     "Иванов Иван",
+		ChatID:  "400",
+		Link:    "https://t.me/+chat",
+		GroupID: "-200",
+	}
+
+	tests := []struct {
+		name    string
+		invite  func() storage.InviteData
+		sendErr error
+		linkErr error
+		setup   func(s *mock_storage.MockStorage)
+		want    string
+		// revoked отозвана ли созданная ссылка
+		revoked bool
+	}{
+		{
+			name:   "personal link",
+			invite: func() storage.InviteData { return invite },
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().SetInviteLink(gomock.Any(), 5, "https://t.me/+personal-200").Return("https://t.me/+personal-200", nil)
+				s.EXPECT().UpdateInviteStatus(gomock.Any(), 5, storage.InviteDone).Return(nil)
+			},
+			want: "Скоро (02.01) у Иванов Иван день рождения, вы подписаны, поэтому заходите https://t.me/+personal-200",
+		},
+		{
+			name:   "personal link saved by another run",
+			invite: func() storage.InviteData { return invite },
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().SetInviteLink(gomock.Any(), 5, "https://t.me/+personal-200").Return("https://t.me/+other", nil)
+				s.EXPECT().UpdateInviteStatus(gomock.Any(), 5, storage.InviteDone).Return(nil)
+			},
+			want:    "Скоро (02.01) у Иванов Иван день рождения, вы подписаны, поэтому заходите https://t.me/+other",
+			revoked: true,
+		},
+		{
+			name: "personal link already created",
+			invite: func() storage.InviteData {
+				i := invite
+				i.PersonalLink = "https://t.me/+old"
+				return i
+			},
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().UpdateInviteStatus(gomock.Any(), 5, storage.InviteDone).Return(nil)
+			},
+			want: "Скоро (02.01) у Иванов Иван день рождения, вы подписаны, поэтому заходите https://t.me/+old",
+		},
+		{
+			name: "forum topic",
+			invite: func() storage.InviteData {
+				i := invite
+				i.GroupID = "-1001234"
+				i.PersonalLink = "https://t.me/+old"
+				i.ThreadID = 7
+				return i
+			},
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().UpdateInviteStatus(gomock.Any(), 5, storage.InviteDone).Return(nil)
+			},
+			want: "Скоро (02.01) у Иванов Иван день рождения, вы подписаны, поэтому заходите https://t.me/+old\nОбсуждение в теме https://t.me/c/1234/7",
+		},
+		{
+			name: "send error keeps invite",
+			invite: func() storage.InviteData {
+				i := invite
+				i.PersonalLink = "https://t.me/+old"
+				return i
+			},
+			sendErr: errors.New("blocked by user"),
+			setup:   func(s *mock_storage.MockStorage) {},
+		},
+		{
+			name:    "link error keeps invite",
+			invite:  func() storage.InviteData { return invite },
+			linkErr: errors.New("not enough rights"),
+			setup:   func(s *mock_storage.MockStorage) {},
+		},
+		{
+			name:   "unsaved link is not sent",
+			invite: func() storage.InviteData { return invite },
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().SetInviteLink(gomock.Any(), 5, "https://t.me/+personal-200").Return("", errors.New("db is down"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			f := messenger.NewFake()
+			f.Err = tt.sendErr
+			f.Fail["CreateInviteLink"] = tt.linkErr
+
+			s.EXPECT().GetNotSentInvites(gomock.Any()).Return([]storage.InviteData{tt.invite()}, nil)
+			tt.setup(s)
+
+			New(s, f, &binder{}, Config{AdminChatID: 100}).inviteGuests(context.Background())
+
+			assert.Equal(t, tt.revoked, len(f.Calls("RevokeInviteLink")) > 0)
+			if tt.want == "" {
+				assert.Empty(t, f.Sent())
+				return
+			}
+			assert.Equal(t, []string{tt.want}, f.Texts(400))
+		})
+	}
+}
+
+// slowMessenger держит Send, пока не закроют release, чтобы рассылка шла дольше тика
+type slowMessenger struct {
+	*messenger.Fake
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (m slowMessenger) Send(msg messenger.Outgoing) (int, error) {
+	m.sending <- struct{}{}
+	<-m.release
+	return m.Fake.Send(msg)
+}
+
+func TestStartSkipsRunningJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock_storage.NewMockStorage(ctrl)
+	m := slowMessenger{Fake: messenger.NewFake(), sending: make(chan struct{}), release: make(chan struct{})}
+
+	invite := storage.InviteData{
+		ID:           5,
+		Date:         time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		FIO:          "Иванов Иван",
+		ChatID:       "400",
+		GroupID:      "-200",
+		PersonalLink: "https://t.me/+old",
+	}
+	// Второй тик не должен снова читать неотправленные приглашения
+	s.EXPECT().GetNotSentInvites(gomock.Any()).Return([]storage.InviteData{invite}, nil).Times(1)
+	s.EXPECT().UpdateInviteStatus(gomock.Any(), 5, storage.InviteDone).Return(nil)
+
+	n := New(s, m, &binder{}, Config{})
+	ctx := context.Background()
+
+	require.True(t, n.start(ctx, "invite guests", n.inviteGuests))
+	<-m.sending
+	assert.False(t, n.start(ctx, "invite guests", n.inviteGuests))
+
+	close(m.release)
+	assert.Eventually(t, func() bool {
+		return n.start(ctx, "invite guests", func(context.Context) {})
+	}, time.Second, 10*time.Millisecond)
+	assert.Len(t, m.Texts(400), 1)
+}
+func TestArchiveBirthday(t *testing.T) {
+	birthday := storage.BirthdayData{ID: 1, ChatID: "-200", InviteLink: "https://t.me/+chat-200"}
+
+	tests := []struct {
+		name  string
+		fail  string
+		setup func(s *mock_storage.MockStorage)
+		calls []string
+	}{
+		{
+			name: "pool chat cleared and released",
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().ReleasePoolChat(gomock.Any(), "-200").Return(nil)
+			},
+			calls: []string{"RevokeInviteLink", "Kick", "Kick", "UnpinAll"},
+		},
+		{
+			name: "not pool chat is left",
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().ReleasePoolChat(gomock.Any(), "-200").Return(storage.ErrPoolChatNotFound)
+			},
+			calls: []string{"RevokeInviteLink", "Kick", "Kick", "UnpinAll", "LeaveChat"},
+		},
+		{
+			name: "kick error drops chat from pool",
+			fail: "Kick",
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().RemovePoolChat(gomock.Any(), "-200").Return(nil)
+			},
+			calls: []string{"RevokeInviteLink", "UnpinAll", "LeaveChat"},
+		},
+		{
+			name: "unpin error drops chat from pool",
+			fail: "UnpinAll",
+			setup: func(s *mock_storage.MockStorage) {
+				s.EXPECT().RemovePoolChat(gomock.Any(), "-200").Return(nil)
+			},
+			calls: []string{"RevokeInviteLink", "Kick", "Kick", "LeaveChat"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			f := messenger.NewFake()
+			if tt.fail != "" {
+				f.Fail[tt.fail] = errors.New("not enough rights")
+			}
+
+			s.EXPECT().GetInvitees(gomock.Any(), 1).Return([]string{"400", "500"}, nil)
+			s.EXPECT().SetBirthdayArchived(gomock.Any(), 1).Return(nil)
+			tt.setup(s)
+
+			New(s, f, &binder{}, Config{}).archiveBirthday(context.Background(), birthday)
+
+			calls := []string{}
+			for _, c := range f.Calls("") {
+				calls = append(calls, c.Method)
+			}
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestAdminAfter(t *testing.T) {
+	admins := []storage.AdminData{{ChatID: "100", Role: storage.AdminRoleOwner}, {ChatID: "101"}, {ChatID: "102"}}
+
+	tests := []struct {
+		name   string
+		admins []storage.AdminData
+		after  string
+		want   int64
+	}{
+		{name: "next admin", admins: admins, after: "100", want: 101},
+		{name: "wraps around", admins: admins, after: "102", want: 100},
+		{name: "removed admin starts from first", admins: admins, after: "999", want: 100},
+		{name: "no admins falls back to config", admins: []storage.AdminData{}, after: "100", want: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock_storage.NewMockStorage(ctrl)
+			s.EXPECT().GetAdmins(gomock.Any()).Return(tt.admins, nil)
+
+			n := New(s, messenger.NewFake(), &binder{}, Config{AdminChatID: 42})
+			assert.Equal(t, tt.want, n.adminAfter(context.Background(), tt.after))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// Ограничения Telegram на название и описание беседы
+const (
+	maxChatTitleLen       = 128
+	maxChatDescriptionLen = 255
+)
+
+// assignPoolChat занимает под день рождения беседу из пула, оформляет и привязывает её без участия админа,
+// false если свободных бесед нет и нужно просить админа создать беседу вручную
+func (n *Notifier) assignPoolChat(ctx context.Context, birthday storage.BirthdayData) bool {
+	chatIDStr, err := n.s.TakePoolChat(ctx, birthday.ID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrPoolEmpty) {
+			log.Err(err).Msg("error taking pool chat")
+		}
+		return false
+	}
+
+	chatID, err := strconv.ParseInt(chatIDStr, 10, 64)
+	if err != nil {
+		log.Err(err).Msg("error convering chat id, should be impossible")
+		n.releasePoolChat(ctx, chatIDStr)
+		return false
+	}
+
+	link, err := n.a.ChatLink(chatID)
+	if err != nil {
+		log.Err(err).Msg("error getting pool chat invite link")
+		n.dropPoolChat(ctx, chatIDStr)
+		return false
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		log.Err(err).Msg("error generating uuid")
+		n.releasePoolChat(ctx, chatIDStr)
+		return false
+	}
+	code := uuid.String()
+
+	if err = n.s.SetCode(ctx, birthday.ID, code); err != nil {
+		log.Err(err).Msg("error setting code")
+		n.releasePoolChat(ctx, chatIDStr)
+		return false
+	}
+
+	if err = n.s.UpdateLinkAndChatIDByCode(ctx, code, chatIDStr, link); err != nil {
+		log.Err(err).Msg("error binding pool chat")
+		n.releasePoolChat(ctx, chatIDStr)
+		return false
+	}
+
+	n.decorateChat(chatID, birthday)
+	n.b.Bind(ctx, chatID, birthday)
+	n.warnPoolLow(ctx)
+
+	return true
+}
+
+// decorateChat переименовывает беседу в честь именинника и ставит описание и фото, ошибки не критичны
+func (n *Notifier) decorateChat(chatID int64, birthday storage.BirthdayData) {
+	title := messenger.Truncate(fmt.Sprintf("ДР %s %s", birthday.FIO, birthday.Date.Format("02.01")), maxChatTitleLen)
+	if err := n.a.SetChatTitle(chatID, title); err != nil {
+		log.Err(err).Msg("error setting pool chat title")
+	}
+
+	description := messenger.Truncate(
+		fmt.Sprintf("Беседа дня рождения %s (%s), здесь выбираем подарок и скидываемся, именинника не зовём", birthday.FIO, birthday.Date.Format("02.01")),
+		maxChatDescriptionLen,
+	)
+	if err := n.a.SetChatDescription(chatID, description); err != nil {
+		log.Err(err).Msg("error setting pool chat description")
+	}
+
+	if n.cfg.PoolChatPhoto == "" {
+		return
+	}
+
+	if err := n.a.SetChatPhoto(chatID, n.cfg.PoolChatPhoto); err != nil {
+		log.Err(err).Msg("error setting pool chat photo")
+	}
+}
+
+// releasePoolChat возвращает в пул беседу, которую заняли, но так и не привязали
+func (n *Notifier) releasePoolChat(ctx context.Context, chatID string) {
+	if err := n.s.ReleasePoolChat(ctx, chatID); err != nil {
+		log.Err(err).Msg("error releasing pool chat")
+	}
+}
+
+// dropPoolChat убирает из пула беседу, в которой бот не может работать, чтобы не занимать её снова
+func (n *Notifier) dropPoolChat(ctx context.Context, chatID string) {
+	if err := n.s.RemovePoolChat(ctx, chatID); err != nil {
+		log.Err(err).Msg("error removing pool chat")
+	}
+
+	n.notifyAdmins(ctx, fmt.Sprintf("Не получилось создать ссылку в беседе %s из пула, я убрал её оттуда, проверьте что я там админ и добавьте снова командой /pool add", chatID))
+}
+
+// warnPoolLow предупреждает админов, что свободных бесед в пуле осталось мало
+func (n *Notifier) warnPoolLow(ctx context.Context) {
+	free, err := n.s.CountFreePoolChats(ctx)
+	if err != nil {
+		log.Err(err).Msg("error counting pool chats")
+		return
+	}
+	if free >= n.cfg.PoolLowThreshold {
+		return
+	}
+
+	n.notifyAdmins(ctx, fmt.Sprintf("В пуле осталось свободных бесед: %d, создайте новые и добавьте их командой /pool add", free))
+}
@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/smakimka/balb/internal/bot/messenger"
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+// admins все, между кем распределяются запросы создать беседу, если список недоступен - только AdminChatID
+func (n *Notifier) admins(ctx context.Context) []int64 {
+	res := []int64{}
+
+	admins, err := n.s.GetAdmins(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting admins")
+	}
+	for _, admin := range admins {
+		chatID, err := strconv.ParseInt(admin.ChatID, 10, 64)
+		if err != nil {
+			log.Err(err).Msg("error convering chat id, should be impossible")
+			continue
+		}
+		res = append(res, chatID)
+	}
+
+	if len(res) == 0 {
+		return []int64{int64(n.cfg.AdminChatID)}
+	}
+
+	return res
+}
+
+// notifyAdmins отправляет всем админам сообщение о том, что требует их внимания
+func (n *Notifier) notifyAdmins(ctx context.Context, text string) {
+	for _, adminChatID := range n.admins(ctx) {
+		if _, err := n.a.Send(messenger.Text(adminChatID, text)); err != nil {
+			log.Err(err).Msg("error notifying admin")
+		}
+	}
+}
+
+// adminAfter следующий по кругу админ после того, кому запрос уже отправляли
+func (n *Notifier) adminAfter(ctx context.Context, adminChatID string) int64 {
+	admins := n.admins(ctx)
+	i := slices.IndexFunc(admins, func(admin int64) bool { return fmt.Sprint(admin) == adminChatID })
+
+	return admins[(i+1)%len(admins)]
+}
+
+// requestChat просит очередного админа создать беседу для дня рождения
+func (n *Notifier) requestChat(ctx context.Context, birthday storage.BirthdayData) {
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		log.Err(err).Msg("error generating uuid")
+		return
+	}
+	code := uuid.String()
+
+	if err = n.s.SetCode(ctx, birthday.ID, code); err != nil {
+		log.Err(err).Msg("error setting code")
+		return
+	}
+
+	admins := n.admins(ctx)
+	admin := admins[(n.nextAdmin.Add(1)-1)%uint64(len(admins))]
+
+	msg := messenger.Text(
+		admin,
+		fmt.Sprintf("Скоро (%s) день рождения у %s, пожадуйста создайте чат, дайте мне там админа и введите в нём команду '/birthday %s'",
+			birthday.Date.Format("02.01"), birthday.FIO, code),
+	)
+	if _, err = n.a.Send(msg); err != nil {
+		log.Err(err).Msg("error sending create chat request, this is bad")
+		if err = n.s.SetCode(ctx, birthday.ID, ""); err != nil {
+			log.Err(err).Msg("critical, cerror deleting code after msg to admin couldn't be sent")
+		}
+		return
+	}
+
+	if err = n.s.SetRequested(ctx, birthday.ID, fmt.Sprint(admin), storage.CodeExpiry(time.Now().UTC(), n.cfg.CodeTTL)); err != nil {
+		log.Err(err).Msg("error saving chat request")
+	}
+}
+
+// remindAdmins повторяет запросы, на которые не ответили за RequestRemindAfter, следующему админу.
+// Если код к этому времени истёк, вместо него выдаётся новый
+func (n *Notifier) remindAdmins(ctx context.Context) {
+	if n.cfg.RequestRemindAfter <= 0 {
+		return
+	}
+
+	birthdays, err := n.s.GetPendingBirthdays(ctx)
+	if err != nil {
+		log.Err(err).Msg("error getting pending birthdays")
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, birthday := range birthdays {
+		if now.Sub(birthday.RequestedAt) < n.cfg.RequestRemindAfter {
+			continue
+		}
+
+		codeExpiresAt := birthday.CodeExpiresAt
+		if birthday.CodeExpired(now) {
+			uuid, err := uuid.NewRandom()
+			if err != nil {
+				log.Err(err).Msg("error generating uuid")
+				continue
+			}
+
+			codeExpiresAt = storage.CodeExpiry(now, n.cfg.CodeTTL)
+			regenerated, err := n.s.RegenCode(ctx, birthday.Code, uuid.String(), codeExpiresAt)
+			if err != nil {
+				log.Err(err).Msg("error regenerating code")
+				continue
+			}
+			birthday.Code = regenerated.Code
+		}
+
+		admin := n.adminAfter(ctx, birthday.RequestedAdmin)
+		msg := messenger.Text(
+			admin,
+			fmt.Sprintf("Напоминание %d: скоро (%s) день рождения у %s, а беседы всё ещё нет. Пожалуйста создайте чат, дайте мне там админа и введите в нём команду '/birthday %s'",
+				birthday.RequestCount, birthday.Date.Format("02.01"), birthday.FIO, birthday.Code),
+		)
+		if _, err = n.a.Send(msg); err != nil {
+			log.Err(err).Msg("error sending create chat reminder")
+			continue
+		}
+
+		if err = n.s.SetRequested(ctx, birthday.ID, fmt.Sprint(admin), codeExpiresAt); err != nil {
+			log.Err(err).Msg("error saving chat request")
+		}
+	}
+}
@@ -9,11 +9,13 @@ import (
 
 func New(s storage.Storage) chi.Router {
 	notifyHandler := handlers.NewNotifyHandler(s)
+	wishlistHandler := handlers.NewWishlistHandler(s)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 
 	r.Post("/notify", notifyHandler.ServeHTTP)
+	r.Post("/wishlist", wishlistHandler.ServeHTTP)
 
 	return r
 }
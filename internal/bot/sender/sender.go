@@ -0,0 +1,334 @@
+// Package sender единая очередь исходящих сообщений бота с учётом лимитов Telegram:
+// общего (около 30 сообщений в секунду) и на один чат, с повтором после 429 и приоритетами
+package sender
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrStopped = errors.New("sender stopped")
+
+// Priority ответы пользователям уходят раньше массовых рассылок
+type Priority int
+
+const (
+	PriorityHigh Priority = iota
+	PriorityLow  Priority = iota
+)
+
+type Config struct {
+	// GlobalRate сколько сообщений в секунду бот отправляет всего
+	GlobalRate int
+	// ChatInterval пауза между сообщениями в один личный чат, GroupInterval - в одну беседу
+	ChatInterval  time.Duration
+	GroupInterval time.Duration
+	// MaxRetries сколько раз повторять сообщение после 429
+	MaxRetries int
+}
+
+// Stats счётчики для логов
+type Stats struct {
+	Queued  int64
+	Sent    int64
+	Retried int64
+	Failed  int64
+}
+
+type job struct {
+	chatID int64
+	do     func() error
+	done   chan error
+}
+
+type Sender struct {
+	a   *tgbotapi.BotAPI
+	cfg Config
+
+	queues  [2]chan *job
+	stopped chan struct{}
+
+	m          sync.Mutex
+	nextGlobal time.Time
+	nextChat   map[int64]time.Time
+
+	queued  atomic.Int64
+	sent    atomic.Int64
+	retried atomic.Int64
+	failed  atomic.Int64
+}
+
+func New(a *tgbotapi.BotAPI, cfg Config) *Sender {
+	return &Sender{
+		a:        a,
+		cfg:      cfg,
+		queues:   [2]chan *job{make(chan *job, 100), make(chan *job, 100)},
+		stopped:  make(chan struct{}),
+		nextChat: map[int64]time.Time{},
+	}
+}
+
+// Queue отправляет через Sender с заданным приоритетом, методы повторяют tgbotapi.BotAPI
+type Queue struct {
+	s        *Sender
+	priority Priority
+}
+
+func (s *Sender) Queue(priority Priority) *Queue {
+	return &Queue{s: s, priority: priority}
+}
+
+// Send ставит сообщение в очередь и ждёт, пока оно уйдёт
+func (q *Queue) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var res tgbotapi.Message
+	err := q.s.enqueue(chattableChatID(c), q.priority, func() error {
+		var err error
+		res, err = q.s.a.Send(c)
+		return err
+	})
+
+	return res, err
+}
+
+// MakeRequest для методов, которых нет в tgbotapi, например отправки в тему форума
+func (q *Queue) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	var res *tgbotapi.APIResponse
+	err := q.s.enqueue(chatID(params), q.priority, func() error {
+		var err error
+		res, err = q.s.a.MakeRequest(endpoint, params)
+		return err
+	})
+
+	return res, err
+}
+
+// chattableChatID чат, куда отправляется сообщение. У tgbotapi параметры запроса закрыты,
+// но у всех конфигов сообщений есть поле ChatID (из BaseChat или BaseEdit)
+func chattableChatID(c tgbotapi.Chattable) int64 {
+	v := reflect.Indirect(reflect.ValueOf(c))
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+
+	f := v.FieldByName("ChatID")
+	if !f.IsValid() || f.Kind() != reflect.Int64 {
+		return 0
+	}
+
+	return f.Int()
+}
+
+// chatID 0 если чат не числовой, тогда действует только общий лимит
+func chatID(params tgbotapi.Params) int64 {
+	id, err := strconv.ParseInt(params["chat_id"], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+func (s *Sender) enqueue(chatID int64, priority Priority, do func() error) error {
+	j := &job{chatID: chatID, do: do, done: make(chan error, 1)}
+
+	s.queued.Add(1)
+	select {
+	case s.queues[priority] <- j:
+	case <-s.stopped:
+		s.queued.Add(-1)
+		return ErrStopped
+	}
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-s.stopped:
+		return ErrStopped
+	}
+}
+
+func (s *Sender) Stats() Stats {
+	return Stats{Queued: s.queued.Load(), Sent: s.sent.Load(), Retried: s.retried.Load(), Failed: s.failed.Load()}
+}
+
+// Run раздаёт сообщения по лимитам, пока не отменён ctx
+func (s *Sender) Run(ctx context.Context) {
+	defer close(s.stopped)
+
+	statsTicker := time.NewTicker(time.Minute)
+	defer statsTicker.Stop()
+
+	// pending ждут своей очереди по лимиту чата, по порядку поступления
+	pending := [2][]*job{}
+	for {
+		select {
+		case <-statsTicker.C:
+			s.logStats()
+		default:
+		}
+
+		for priority := range s.queues {
+			pending[priority] = drain(s.queues[priority], pending[priority])
+		}
+
+		j, wait := s.next(&pending)
+		if j == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case j := <-s.queues[PriorityHigh]:
+				pending[PriorityHigh] = append(pending[PriorityHigh], j)
+			case j := <-s.queues[PriorityLow]:
+				pending[PriorityLow] = append(pending[PriorityLow], j)
+			case <-timer.C:
+			}
+			timer.Stop()
+			continue
+		}
+
+		if !s.waitGlobal(ctx) {
+			return
+		}
+		go s.send(j)
+	}
+}
+
+func drain(queue chan *job, pending []*job) []*job {
+	for {
+		select {
+		case j := <-queue:
+			pending = append(pending, j)
+		default:
+			return pending
+		}
+	}
+}
+
+// next достаёт первое сообщение, чат которого уже можно писать, иначе сколько ждать до ближайшего такого
+func (s *Sender) next(pending *[2][]*job) (*job, time.Duration) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	now := time.Now()
+	wait := time.Minute
+	for priority := range pending {
+		for i, j := range pending[priority] {
+			at := s.nextChat[j.chatID]
+			if j.chatID != 0 && at.After(now) {
+				wait = min(wait, at.Sub(now))
+				continue
+			}
+
+			// Раньше в этот чат сообщений нет: иначе они бы уже были пропущены как неготовые выше
+			pending[priority] = append(pending[priority][:i], pending[priority][i+1:]...)
+			s.reserveChat(j.chatID, now)
+			return j, 0
+		}
+	}
+
+	return nil, wait
+}
+
+func (s *Sender) reserveChat(chatID int64, now time.Time) {
+	if chatID == 0 {
+		return
+	}
+
+	interval := s.cfg.ChatInterval
+	if chatID < 0 {
+		interval = s.cfg.GroupInterval
+	}
+	s.nextChat[chatID] = now.Add(interval)
+
+	// Чтобы карта не росла бесконечно, забываем чаты, в которые уже можно писать
+	if len(s.nextChat) > 10000 {
+		for id, at := range s.nextChat {
+			if at.Before(now) {
+				delete(s.nextChat, id)
+			}
+		}
+	}
+}
+
+// waitGlobal ждёт следующего слота общего лимита, а после 429 - окончания retry_after
+func (s *Sender) waitGlobal(ctx context.Context) bool {
+	s.m.Lock()
+	now := time.Now()
+	at := s.nextGlobal
+	if at.Before(now) {
+		at = now
+	}
+	if s.cfg.GlobalRate > 0 {
+		s.nextGlobal = at.Add(time.Second / time.Duration(s.cfg.GlobalRate))
+	}
+	s.m.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// send отправляет сообщение, после 429 ждёт retry_after и пробует снова. Лимит Telegram часто общий на бота,
+// поэтому на это время придерживаются все сообщения, а не только в этот чат
+func (s *Sender) send(j *job) {
+	defer s.queued.Add(-1)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = j.do()
+
+		var tgErr *tgbotapi.Error
+		if !errors.As(err, &tgErr) || tgErr.Code != 429 || attempt >= s.cfg.MaxRetries {
+			break
+		}
+
+		retryAfter := time.Duration(max(tgErr.RetryAfter, 1)) * time.Second
+		log.Warn().Int64("chat_id", j.chatID).Dur("retry_after", retryAfter).Msg("telegram rate limit hit, retrying")
+		s.retried.Add(1)
+
+		s.m.Lock()
+		at := time.Now().Add(retryAfter)
+		if j.chatID != 0 && s.nextChat[j.chatID].Before(at) {
+			s.nextChat[j.chatID] = at
+		}
+		if s.nextGlobal.Before(at) {
+			s.nextGlobal = at
+		}
+		s.m.Unlock()
+
+		time.Sleep(retryAfter)
+	}
+
+	if err != nil {
+		s.failed.Add(1)
+	} else {
+		s.sent.Add(1)
+	}
+	j.done <- err
+}
+
+func (s *Sender) logStats() {
+	stats := s.Stats()
+	log.Info().
+		Int64("queued", stats.Queued).
+		Int64("sent", stats.Sent).
+		Int64("retried", stats.Retried).
+		Int64("failed", stats.Failed).
+		Msg("sender stats")
+}
@@ -0,0 +1,127 @@
+package sender_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smakimka/balb/internal/bot/sender"
+)
+
+// fakeTelegram отвечает на getMe и sendMessage, первые limited sendMessage получают 429
+type fakeTelegram struct {
+	m       sync.Mutex
+	limited int
+	sent    []time.Time
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if strings.HasSuffix(r.URL.Path, "/getMe") {
+		w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot","username":"bot"}}`))
+		return
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.limited > 0 {
+		f.limited--
+		w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":1}}`))
+		return
+	}
+
+	f.sent = append(f.sent, time.Now())
+	w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":1},"date":0}}`))
+}
+
+func newSender(t *testing.T, f *fakeTelegram, cfg sender.Config) *sender.Sender {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", server.URL+"/bot%s/%s")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s := sender.New(api, cfg)
+	go s.Run(ctx)
+
+	return s
+}
+
+func TestSendRetriesAfterRateLimit(t *testing.T) {
+	f := &fakeTelegram{limited: 1}
+	s := newSender(t, f, sender.Config{GlobalRate: 30, MaxRetries: 3})
+
+	_, err := s.Queue(sender.PriorityHigh).Send(tgbotapi.NewMessage(1, "hi"))
+	require.NoError(t, err)
+
+	stats := s.Stats()
+	assert.Equal(t, int64(1), stats.Sent)
+	assert.Equal(t, int64(1), stats.Retried)
+	assert.Equal(t, int64(0), stats.Failed)
+}
+
+func TestSendGivesUpAfterMaxRetries(t *testing.T) {
+	f := &fakeTelegram{limited: 1}
+	s := newSender(t, f, sender.Config{GlobalRate: 30})
+
+	_, err := s.Queue(sender.PriorityHigh).Send(tgbotapi.NewMessage(1, "hi"))
+	require.Error(t, err)
+	assert.Equal(t, int64(1), s.Stats().Failed)
+}
+
+func TestSendKeepsChatInterval(t *testing.T) {
+	f := &fakeTelegram{}
+	s := newSender(t, f, sender.Config{GlobalRate: 30, ChatInterval: 200 * time.Millisecond})
+
+	wg := sync.WaitGroup{}
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Queue(sender.PriorityLow).Send(tgbotapi.NewMessage(1, "hi"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, f.sent, 3)
+	assert.GreaterOrEqual(t, f.sent[2].Sub(f.sent[0]), 350*time.Millisecond)
+}
+
+func TestRateLimitHoldsOtherChats(t *testing.T) {
+	f := &fakeTelegram{limited: 1}
+	s := newSender(t, f, sender.Config{GlobalRate: 30, MaxRetries: 3})
+
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := s.Queue(sender.PriorityHigh).Send(tgbotapi.NewMessage(1, "hi"))
+		assert.NoError(t, err)
+	}()
+
+	// Второе сообщение в другой чат встаёт в очередь, когда первое уже получило 429
+	time.Sleep(200 * time.Millisecond)
+	_, err := s.Queue(sender.PriorityHigh).Send(tgbotapi.NewMessage(2, "hi"))
+	require.NoError(t, err)
+	wg.Wait()
+
+	require.Len(t, f.sent, 2)
+	for _, at := range f.sent {
+		assert.GreaterOrEqual(t, at.Sub(start), 900*time.Millisecond)
+	}
+}
@@ -0,0 +1,968 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: storage.go
+//
+// Generated by this command:
+//
+//	mockgen -source storage.go -destination mock/storage.go
+//
+
+// Package mock_storage is a generated GoMock package.
+package mock_storage
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	storage "github.com/smakimka/balb/internal/bot/storage"
+	model "github.com/smakimka/balb/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// AddAdmin mocks base method.
+func (m *MockStorage) AddAdmin(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAdmin", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAdmin indicates an expected call of AddAdmin.
+func (mr *MockStorageMockRecorder) AddAdmin(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAdmin", reflect.TypeOf((*MockStorage)(nil).AddAdmin), ctx, chatID)
+}
+
+// AddContribution mocks base method.
+func (m *MockStorage) AddContribution(ctx context.Context, birthdayID int, c storage.ContributionData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddContribution", ctx, birthdayID, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddContribution indicates an expected call of AddContribution.
+func (mr *MockStorageMockRecorder) AddContribution(ctx, birthdayID, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddContribution", reflect.TypeOf((*MockStorage)(nil).AddContribution), ctx, birthdayID, c)
+}
+
+// AddOrganizer mocks base method.
+func (m *MockStorage) AddOrganizer(ctx context.Context, birthdayID int, organizerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganizer", ctx, birthdayID, organizerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganizer indicates an expected call of AddOrganizer.
+func (mr *MockStorageMockRecorder) AddOrganizer(ctx, birthdayID, organizerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganizer", reflect.TypeOf((*MockStorage)(nil).AddOrganizer), ctx, birthdayID, organizerID)
+}
+
+// AddPoolChat mocks base method.
+func (m *MockStorage) AddPoolChat(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPoolChat", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPoolChat indicates an expected call of AddPoolChat.
+func (mr *MockStorageMockRecorder) AddPoolChat(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPoolChat", reflect.TypeOf((*MockStorage)(nil).AddPoolChat), ctx, chatID)
+}
+
+// AddTokenFailure mocks base method.
+func (m *MockStorage) AddTokenFailure(ctx context.Context, chatID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTokenFailure", ctx, chatID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTokenFailure indicates an expected call of AddTokenFailure.
+func (mr *MockStorageMockRecorder) AddTokenFailure(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTokenFailure", reflect.TypeOf((*MockStorage)(nil).AddTokenFailure), ctx, chatID)
+}
+
+// CheckRegistrationCode mocks base method.
+func (m *MockStorage) CheckRegistrationCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRegistrationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckRegistrationCode indicates an expected call of CheckRegistrationCode.
+func (mr *MockStorageMockRecorder) CheckRegistrationCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRegistrationCode", reflect.TypeOf((*MockStorage)(nil).CheckRegistrationCode), ctx, code)
+}
+
+// CountFreePoolChats mocks base method.
+func (m *MockStorage) CountFreePoolChats(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFreePoolChats", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFreePoolChats indicates an expected call of CountFreePoolChats.
+func (mr *MockStorageMockRecorder) CountFreePoolChats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFreePoolChats", reflect.TypeOf((*MockStorage)(nil).CountFreePoolChats), ctx)
+}
+
+// CreateBirthday mocks base method.
+func (m *MockStorage) CreateBirthday(ctx context.Context, r *model.NotifyRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBirthday", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBirthday indicates an expected call of CreateBirthday.
+func (mr *MockStorageMockRecorder) CreateBirthday(ctx, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBirthday", reflect.TypeOf((*MockStorage)(nil).CreateBirthday), ctx, r)
+}
+
+// CreatePoll mocks base method.
+func (m *MockStorage) CreatePoll(ctx context.Context, p *storage.PollData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePoll", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePoll indicates an expected call of CreatePoll.
+func (mr *MockStorageMockRecorder) CreatePoll(ctx, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePoll", reflect.TypeOf((*MockStorage)(nil).CreatePoll), ctx, p)
+}
+
+// CreateRegistrationCode mocks base method.
+func (m *MockStorage) CreateRegistrationCode(ctx context.Context, c *storage.RegistrationCodeData, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRegistrationCode", ctx, c, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRegistrationCode indicates an expected call of CreateRegistrationCode.
+func (mr *MockStorageMockRecorder) CreateRegistrationCode(ctx, c, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRegistrationCode", reflect.TypeOf((*MockStorage)(nil).CreateRegistrationCode), ctx, c, ttl)
+}
+
+// DecideApplication mocks base method.
+func (m *MockStorage) DecideApplication(ctx context.Context, chatID string, status int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideApplication", ctx, chatID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecideApplication indicates an expected call of DecideApplication.
+func (mr *MockStorageMockRecorder) DecideApplication(ctx, chatID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideApplication", reflect.TypeOf((*MockStorage)(nil).DecideApplication), ctx, chatID, status)
+}
+
+// GetActiveBirthdays mocks base method.
+func (m *MockStorage) GetActiveBirthdays(ctx context.Context) ([]storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveBirthdays", ctx)
+	ret0, _ := ret[0].([]storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveBirthdays indicates an expected call of GetActiveBirthdays.
+func (mr *MockStorageMockRecorder) GetActiveBirthdays(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveBirthdays", reflect.TypeOf((*MockStorage)(nil).GetActiveBirthdays), ctx)
+}
+
+// GetActiveRegistrationCodes mocks base method.
+func (m *MockStorage) GetActiveRegistrationCodes(ctx context.Context) ([]storage.RegistrationCodeData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveRegistrationCodes", ctx)
+	ret0, _ := ret[0].([]storage.RegistrationCodeData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveRegistrationCodes indicates an expected call of GetActiveRegistrationCodes.
+func (mr *MockStorageMockRecorder) GetActiveRegistrationCodes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveRegistrationCodes", reflect.TypeOf((*MockStorage)(nil).GetActiveRegistrationCodes), ctx)
+}
+
+// GetAdmin mocks base method.
+func (m *MockStorage) GetAdmin(ctx context.Context, chatID string) (storage.AdminData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdmin", ctx, chatID)
+	ret0, _ := ret[0].(storage.AdminData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdmin indicates an expected call of GetAdmin.
+func (mr *MockStorageMockRecorder) GetAdmin(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdmin", reflect.TypeOf((*MockStorage)(nil).GetAdmin), ctx, chatID)
+}
+
+// GetAdmins mocks base method.
+func (m *MockStorage) GetAdmins(ctx context.Context) ([]storage.AdminData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdmins", ctx)
+	ret0, _ := ret[0].([]storage.AdminData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdmins indicates an expected call of GetAdmins.
+func (mr *MockStorageMockRecorder) GetAdmins(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdmins", reflect.TypeOf((*MockStorage)(nil).GetAdmins), ctx)
+}
+
+// GetApplication mocks base method.
+func (m *MockStorage) GetApplication(ctx context.Context, chatID string) (storage.ApplicationData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApplication", ctx, chatID)
+	ret0, _ := ret[0].(storage.ApplicationData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApplication indicates an expected call of GetApplication.
+func (mr *MockStorageMockRecorder) GetApplication(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApplication", reflect.TypeOf((*MockStorage)(nil).GetApplication), ctx, chatID)
+}
+
+// GetBirthdayByChatID mocks base method.
+func (m *MockStorage) GetBirthdayByChatID(ctx context.Context, chatID string) (storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBirthdayByChatID", ctx, chatID)
+	ret0, _ := ret[0].(storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBirthdayByChatID indicates an expected call of GetBirthdayByChatID.
+func (mr *MockStorageMockRecorder) GetBirthdayByChatID(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBirthdayByChatID", reflect.TypeOf((*MockStorage)(nil).GetBirthdayByChatID), ctx, chatID)
+}
+
+// GetBirthdayByCode mocks base method.
+func (m *MockStorage) GetBirthdayByCode(ctx context.Context, code string) (storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBirthdayByCode", ctx, code)
+	ret0, _ := ret[0].(storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBirthdayByCode indicates an expected call of GetBirthdayByCode.
+func (mr *MockStorageMockRecorder) GetBirthdayByCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBirthdayByCode", reflect.TypeOf((*MockStorage)(nil).GetBirthdayByCode), ctx, code)
+}
+
+// GetBirthdayInvites mocks base method.
+func (m *MockStorage) GetBirthdayInvites(ctx context.Context, birthdayID int) ([]storage.InviteData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBirthdayInvites", ctx, birthdayID)
+	ret0, _ := ret[0].([]storage.InviteData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBirthdayInvites indicates an expected call of GetBirthdayInvites.
+func (mr *MockStorageMockRecorder) GetBirthdayInvites(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBirthdayInvites", reflect.TypeOf((*MockStorage)(nil).GetBirthdayInvites), ctx, birthdayID)
+}
+
+// GetChangedWishlists mocks base method.
+func (m *MockStorage) GetChangedWishlists(ctx context.Context) ([]storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangedWishlists", ctx)
+	ret0, _ := ret[0].([]storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangedWishlists indicates an expected call of GetChangedWishlists.
+func (mr *MockStorageMockRecorder) GetChangedWishlists(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangedWishlists", reflect.TypeOf((*MockStorage)(nil).GetChangedWishlists), ctx)
+}
+
+// GetCollection mocks base method.
+func (m *MockStorage) GetCollection(ctx context.Context, birthdayID int) (storage.CollectionData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollection", ctx, birthdayID)
+	ret0, _ := ret[0].(storage.CollectionData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollection indicates an expected call of GetCollection.
+func (mr *MockStorageMockRecorder) GetCollection(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollection", reflect.TypeOf((*MockStorage)(nil).GetCollection), ctx, birthdayID)
+}
+
+// GetDuePolls mocks base method.
+func (m *MockStorage) GetDuePolls(ctx context.Context) ([]storage.PollData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDuePolls", ctx)
+	ret0, _ := ret[0].([]storage.PollData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDuePolls indicates an expected call of GetDuePolls.
+func (mr *MockStorageMockRecorder) GetDuePolls(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDuePolls", reflect.TypeOf((*MockStorage)(nil).GetDuePolls), ctx)
+}
+
+// GetInviteByLink mocks base method.
+func (m *MockStorage) GetInviteByLink(ctx context.Context, link string) (storage.InviteData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteByLink", ctx, link)
+	ret0, _ := ret[0].(storage.InviteData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteByLink indicates an expected call of GetInviteByLink.
+func (mr *MockStorageMockRecorder) GetInviteByLink(ctx, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteByLink", reflect.TypeOf((*MockStorage)(nil).GetInviteByLink), ctx, link)
+}
+
+// GetInvitees mocks base method.
+func (m *MockStorage) GetInvitees(ctx context.Context, birthdayID int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitees", ctx, birthdayID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitees indicates an expected call of GetInvitees.
+func (mr *MockStorageMockRecorder) GetInvitees(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitees", reflect.TypeOf((*MockStorage)(nil).GetInvitees), ctx, birthdayID)
+}
+
+// GetLastOrganized mocks base method.
+func (m *MockStorage) GetLastOrganized(ctx context.Context, chatIDs []string) (map[string]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastOrganized", ctx, chatIDs)
+	ret0, _ := ret[0].(map[string]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastOrganized indicates an expected call of GetLastOrganized.
+func (mr *MockStorageMockRecorder) GetLastOrganized(ctx, chatIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastOrganized", reflect.TypeOf((*MockStorage)(nil).GetLastOrganized), ctx, chatIDs)
+}
+
+// GetNewBirthdays mocks base method.
+func (m *MockStorage) GetNewBirthdays(ctx context.Context) ([]storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNewBirthdays", ctx)
+	ret0, _ := ret[0].([]storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNewBirthdays indicates an expected call of GetNewBirthdays.
+func (mr *MockStorageMockRecorder) GetNewBirthdays(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNewBirthdays", reflect.TypeOf((*MockStorage)(nil).GetNewBirthdays), ctx)
+}
+
+// GetNotJoinedInvites mocks base method.
+func (m *MockStorage) GetNotJoinedInvites(ctx context.Context, after time.Duration) ([]storage.InviteData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotJoinedInvites", ctx, after)
+	ret0, _ := ret[0].([]storage.InviteData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotJoinedInvites indicates an expected call of GetNotJoinedInvites.
+func (mr *MockStorageMockRecorder) GetNotJoinedInvites(ctx, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotJoinedInvites", reflect.TypeOf((*MockStorage)(nil).GetNotJoinedInvites), ctx, after)
+}
+
+// GetNotRemindedCollections mocks base method.
+func (m *MockStorage) GetNotRemindedCollections(ctx context.Context) ([]storage.CollectionData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotRemindedCollections", ctx)
+	ret0, _ := ret[0].([]storage.CollectionData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotRemindedCollections indicates an expected call of GetNotRemindedCollections.
+func (mr *MockStorageMockRecorder) GetNotRemindedCollections(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotRemindedCollections", reflect.TypeOf((*MockStorage)(nil).GetNotRemindedCollections), ctx)
+}
+
+// GetNotSentInvites mocks base method.
+func (m *MockStorage) GetNotSentInvites(ctx context.Context) ([]storage.InviteData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotSentInvites", ctx)
+	ret0, _ := ret[0].([]storage.InviteData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotSentInvites indicates an expected call of GetNotSentInvites.
+func (mr *MockStorageMockRecorder) GetNotSentInvites(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotSentInvites", reflect.TypeOf((*MockStorage)(nil).GetNotSentInvites), ctx)
+}
+
+// GetNotSentWishlistUpdates mocks base method.
+func (m *MockStorage) GetNotSentWishlistUpdates(ctx context.Context) ([]storage.WishlistUpdateData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotSentWishlistUpdates", ctx)
+	ret0, _ := ret[0].([]storage.WishlistUpdateData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotSentWishlistUpdates indicates an expected call of GetNotSentWishlistUpdates.
+func (mr *MockStorageMockRecorder) GetNotSentWishlistUpdates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotSentWishlistUpdates", reflect.TypeOf((*MockStorage)(nil).GetNotSentWishlistUpdates), ctx)
+}
+
+// GetOrganizer mocks base method.
+func (m *MockStorage) GetOrganizer(ctx context.Context, birthdayID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizer", ctx, birthdayID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizer indicates an expected call of GetOrganizer.
+func (mr *MockStorageMockRecorder) GetOrganizer(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizer", reflect.TypeOf((*MockStorage)(nil).GetOrganizer), ctx, birthdayID)
+}
+
+// GetPendingApplications mocks base method.
+func (m *MockStorage) GetPendingApplications(ctx context.Context) ([]storage.ApplicationData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingApplications", ctx)
+	ret0, _ := ret[0].([]storage.ApplicationData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingApplications indicates an expected call of GetPendingApplications.
+func (mr *MockStorageMockRecorder) GetPendingApplications(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingApplications", reflect.TypeOf((*MockStorage)(nil).GetPendingApplications), ctx)
+}
+
+// GetPendingBirthdays mocks base method.
+func (m *MockStorage) GetPendingBirthdays(ctx context.Context) ([]storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingBirthdays", ctx)
+	ret0, _ := ret[0].([]storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingBirthdays indicates an expected call of GetPendingBirthdays.
+func (mr *MockStorageMockRecorder) GetPendingBirthdays(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingBirthdays", reflect.TypeOf((*MockStorage)(nil).GetPendingBirthdays), ctx)
+}
+
+// GetTokenLock mocks base method.
+func (m *MockStorage) GetTokenLock(ctx context.Context, chatID string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenLock", ctx, chatID)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenLock indicates an expected call of GetTokenLock.
+func (mr *MockStorageMockRecorder) GetTokenLock(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenLock", reflect.TypeOf((*MockStorage)(nil).GetTokenLock), ctx, chatID)
+}
+
+// MigrateChat mocks base method.
+func (m *MockStorage) MigrateChat(ctx context.Context, oldChatID, newChatID, link string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateChat", ctx, oldChatID, newChatID, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MigrateChat indicates an expected call of MigrateChat.
+func (mr *MockStorageMockRecorder) MigrateChat(ctx, oldChatID, newChatID, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateChat", reflect.TypeOf((*MockStorage)(nil).MigrateChat), ctx, oldChatID, newChatID, link)
+}
+
+// RegenCode mocks base method.
+func (m *MockStorage) RegenCode(ctx context.Context, oldCode, newCode string, codeExpiresAt time.Time) (storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenCode", ctx, oldCode, newCode, codeExpiresAt)
+	ret0, _ := ret[0].(storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenCode indicates an expected call of RegenCode.
+func (mr *MockStorageMockRecorder) RegenCode(ctx, oldCode, newCode, codeExpiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenCode", reflect.TypeOf((*MockStorage)(nil).RegenCode), ctx, oldCode, newCode, codeExpiresAt)
+}
+
+// ReleasePoolChat mocks base method.
+func (m *MockStorage) ReleasePoolChat(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePoolChat", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePoolChat indicates an expected call of ReleasePoolChat.
+func (mr *MockStorageMockRecorder) ReleasePoolChat(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePoolChat", reflect.TypeOf((*MockStorage)(nil).ReleasePoolChat), ctx, chatID)
+}
+
+// RemoveAdmin mocks base method.
+func (m *MockStorage) RemoveAdmin(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAdmin", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAdmin indicates an expected call of RemoveAdmin.
+func (mr *MockStorageMockRecorder) RemoveAdmin(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAdmin", reflect.TypeOf((*MockStorage)(nil).RemoveAdmin), ctx, chatID)
+}
+
+// RemovePoolChat mocks base method.
+func (m *MockStorage) RemovePoolChat(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePoolChat", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePoolChat indicates an expected call of RemovePoolChat.
+func (mr *MockStorageMockRecorder) RemovePoolChat(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePoolChat", reflect.TypeOf((*MockStorage)(nil).RemovePoolChat), ctx, chatID)
+}
+
+// ReopenApplication mocks base method.
+func (m *MockStorage) ReopenApplication(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReopenApplication", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReopenApplication indicates an expected call of ReopenApplication.
+func (mr *MockStorageMockRecorder) ReopenApplication(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReopenApplication", reflect.TypeOf((*MockStorage)(nil).ReopenApplication), ctx, chatID)
+}
+
+// ResetTokenFailures mocks base method.
+func (m *MockStorage) ResetTokenFailures(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTokenFailures", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTokenFailures indicates an expected call of ResetTokenFailures.
+func (mr *MockStorageMockRecorder) ResetTokenFailures(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTokenFailures", reflect.TypeOf((*MockStorage)(nil).ResetTokenFailures), ctx, chatID)
+}
+
+// RevokeRegistrationCode mocks base method.
+func (m *MockStorage) RevokeRegistrationCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRegistrationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRegistrationCode indicates an expected call of RevokeRegistrationCode.
+func (mr *MockStorageMockRecorder) RevokeRegistrationCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRegistrationCode", reflect.TypeOf((*MockStorage)(nil).RevokeRegistrationCode), ctx, code)
+}
+
+// SaveApplication mocks base method.
+func (m *MockStorage) SaveApplication(ctx context.Context, a storage.ApplicationData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveApplication", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveApplication indicates an expected call of SaveApplication.
+func (mr *MockStorageMockRecorder) SaveApplication(ctx, a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveApplication", reflect.TypeOf((*MockStorage)(nil).SaveApplication), ctx, a)
+}
+
+// SetBirthdayArchived mocks base method.
+func (m *MockStorage) SetBirthdayArchived(ctx context.Context, birthdayID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBirthdayArchived", ctx, birthdayID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBirthdayArchived indicates an expected call of SetBirthdayArchived.
+func (mr *MockStorageMockRecorder) SetBirthdayArchived(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBirthdayArchived", reflect.TypeOf((*MockStorage)(nil).SetBirthdayArchived), ctx, birthdayID)
+}
+
+// SetChatBroken mocks base method.
+func (m *MockStorage) SetChatBroken(ctx context.Context, chatID string, broken bool, link string) ([]storage.BirthdayData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChatBroken", ctx, chatID, broken, link)
+	ret0, _ := ret[0].([]storage.BirthdayData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetChatBroken indicates an expected call of SetChatBroken.
+func (mr *MockStorageMockRecorder) SetChatBroken(ctx, chatID, broken, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChatBroken", reflect.TypeOf((*MockStorage)(nil).SetChatBroken), ctx, chatID, broken, link)
+}
+
+// SetCode mocks base method.
+func (m *MockStorage) SetCode(ctx context.Context, birthdayID int, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCode", ctx, birthdayID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCode indicates an expected call of SetCode.
+func (mr *MockStorageMockRecorder) SetCode(ctx, birthdayID, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCode", reflect.TypeOf((*MockStorage)(nil).SetCode), ctx, birthdayID, code)
+}
+
+// SetCollection mocks base method.
+func (m *MockStorage) SetCollection(ctx context.Context, c *storage.CollectionData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCollection", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCollection indicates an expected call of SetCollection.
+func (mr *MockStorageMockRecorder) SetCollection(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCollection", reflect.TypeOf((*MockStorage)(nil).SetCollection), ctx, c)
+}
+
+// SetCollectionMessageID mocks base method.
+func (m *MockStorage) SetCollectionMessageID(ctx context.Context, birthdayID, messageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCollectionMessageID", ctx, birthdayID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCollectionMessageID indicates an expected call of SetCollectionMessageID.
+func (mr *MockStorageMockRecorder) SetCollectionMessageID(ctx, birthdayID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCollectionMessageID", reflect.TypeOf((*MockStorage)(nil).SetCollectionMessageID), ctx, birthdayID, messageID)
+}
+
+// SetCollectionReminded mocks base method.
+func (m *MockStorage) SetCollectionReminded(ctx context.Context, birthdayID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCollectionReminded", ctx, birthdayID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCollectionReminded indicates an expected call of SetCollectionReminded.
+func (mr *MockStorageMockRecorder) SetCollectionReminded(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCollectionReminded", reflect.TypeOf((*MockStorage)(nil).SetCollectionReminded), ctx, birthdayID)
+}
+
+// SetInviteLink mocks base method.
+func (m *MockStorage) SetInviteLink(ctx context.Context, inviteID int, link string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInviteLink", ctx, inviteID, link)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetInviteLink indicates an expected call of SetInviteLink.
+func (mr *MockStorageMockRecorder) SetInviteLink(ctx, inviteID, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInviteLink", reflect.TypeOf((*MockStorage)(nil).SetInviteLink), ctx, inviteID, link)
+}
+
+// SetInviteMembership mocks base method.
+func (m *MockStorage) SetInviteMembership(ctx context.Context, groupID, chatID string, joined bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInviteMembership", ctx, groupID, chatID, joined)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInviteMembership indicates an expected call of SetInviteMembership.
+func (mr *MockStorageMockRecorder) SetInviteMembership(ctx, groupID, chatID, joined any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInviteMembership", reflect.TypeOf((*MockStorage)(nil).SetInviteMembership), ctx, groupID, chatID, joined)
+}
+
+// SetInviteReminded mocks base method.
+func (m *MockStorage) SetInviteReminded(ctx context.Context, inviteID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInviteReminded", ctx, inviteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetInviteReminded indicates an expected call of SetInviteReminded.
+func (mr *MockStorageMockRecorder) SetInviteReminded(ctx, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInviteReminded", reflect.TypeOf((*MockStorage)(nil).SetInviteReminded), ctx, inviteID)
+}
+
+// SetOwner mocks base method.
+func (m *MockStorage) SetOwner(ctx context.Context, chatID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwner", ctx, chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOwner indicates an expected call of SetOwner.
+func (mr *MockStorageMockRecorder) SetOwner(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwner", reflect.TypeOf((*MockStorage)(nil).SetOwner), ctx, chatID)
+}
+
+// SetPollClosed mocks base method.
+func (m *MockStorage) SetPollClosed(ctx context.Context, pollID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPollClosed", ctx, pollID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPollClosed indicates an expected call of SetPollClosed.
+func (mr *MockStorageMockRecorder) SetPollClosed(ctx, pollID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPollClosed", reflect.TypeOf((*MockStorage)(nil).SetPollClosed), ctx, pollID)
+}
+
+// SetRequested mocks base method.
+func (m *MockStorage) SetRequested(ctx context.Context, birthdayID int, adminChatID string, codeExpiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRequested", ctx, birthdayID, adminChatID, codeExpiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRequested indicates an expected call of SetRequested.
+func (mr *MockStorageMockRecorder) SetRequested(ctx, birthdayID, adminChatID, codeExpiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRequested", reflect.TypeOf((*MockStorage)(nil).SetRequested), ctx, birthdayID, adminChatID, codeExpiresAt)
+}
+
+// SetTokenLock mocks base method.
+func (m *MockStorage) SetTokenLock(ctx context.Context, chatID string, lock time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTokenLock", ctx, chatID, lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTokenLock indicates an expected call of SetTokenLock.
+func (mr *MockStorageMockRecorder) SetTokenLock(ctx, chatID, lock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTokenLock", reflect.TypeOf((*MockStorage)(nil).SetTokenLock), ctx, chatID, lock)
+}
+
+// SetTopic mocks base method.
+func (m *MockStorage) SetTopic(ctx context.Context, birthdayID int, chatID string, threadID int, link string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTopic", ctx, birthdayID, chatID, threadID, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTopic indicates an expected call of SetTopic.
+func (mr *MockStorageMockRecorder) SetTopic(ctx, birthdayID, chatID, threadID, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTopic", reflect.TypeOf((*MockStorage)(nil).SetTopic), ctx, birthdayID, chatID, threadID, link)
+}
+
+// SetWishlistMessageID mocks base method.
+func (m *MockStorage) SetWishlistMessageID(ctx context.Context, birthdayID, messageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistMessageID", ctx, birthdayID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistMessageID indicates an expected call of SetWishlistMessageID.
+func (mr *MockStorageMockRecorder) SetWishlistMessageID(ctx, birthdayID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistMessageID", reflect.TypeOf((*MockStorage)(nil).SetWishlistMessageID), ctx, birthdayID, messageID)
+}
+
+// SetWishlistUpdateSent mocks base method.
+func (m *MockStorage) SetWishlistUpdateSent(ctx context.Context, updateID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistUpdateSent", ctx, updateID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistUpdateSent indicates an expected call of SetWishlistUpdateSent.
+func (mr *MockStorageMockRecorder) SetWishlistUpdateSent(ctx, updateID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistUpdateSent", reflect.TypeOf((*MockStorage)(nil).SetWishlistUpdateSent), ctx, updateID)
+}
+
+// TakePoolChat mocks base method.
+func (m *MockStorage) TakePoolChat(ctx context.Context, birthdayID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakePoolChat", ctx, birthdayID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakePoolChat indicates an expected call of TakePoolChat.
+func (mr *MockStorageMockRecorder) TakePoolChat(ctx, birthdayID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakePoolChat", reflect.TypeOf((*MockStorage)(nil).TakePoolChat), ctx, birthdayID)
+}
+
+// UpdateInviteStatus mocks base method.
+func (m *MockStorage) UpdateInviteStatus(ctx context.Context, inviteID, status int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInviteStatus", ctx, inviteID, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateInviteStatus indicates an expected call of UpdateInviteStatus.
+func (mr *MockStorageMockRecorder) UpdateInviteStatus(ctx, inviteID, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInviteStatus", reflect.TypeOf((*MockStorage)(nil).UpdateInviteStatus), ctx, inviteID, status)
+}
+
+// UpdateLinkAndChatIDByCode mocks base method.
+func (m *MockStorage) UpdateLinkAndChatIDByCode(ctx context.Context, code, chatID, link string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLinkAndChatIDByCode", ctx, code, chatID, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLinkAndChatIDByCode indicates an expected call of UpdateLinkAndChatIDByCode.
+func (mr *MockStorageMockRecorder) UpdateLinkAndChatIDByCode(ctx, code, chatID, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLinkAndChatIDByCode", reflect.TypeOf((*MockStorage)(nil).UpdateLinkAndChatIDByCode), ctx, code, chatID, link)
+}
+
+// UpdateWishlist mocks base method.
+func (m *MockStorage) UpdateWishlist(ctx context.Context, c *model.WishlistChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWishlist", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWishlist indicates an expected call of UpdateWishlist.
+func (mr *MockStorageMockRecorder) UpdateWishlist(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWishlist", reflect.TypeOf((*MockStorage)(nil).UpdateWishlist), ctx, c)
+}
+
+// UseRegistrationCode mocks base method.
+func (m *MockStorage) UseRegistrationCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRegistrationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRegistrationCode indicates an expected call of UseRegistrationCode.
+func (mr *MockStorageMockRecorder) UseRegistrationCode(ctx, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRegistrationCode", reflect.TypeOf((*MockStorage)(nil).UseRegistrationCode), ctx, code)
+}
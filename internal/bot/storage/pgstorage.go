@@ -2,7 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/smakimka/balb/internal/model"
@@ -51,6 +55,143 @@ func (s *PGStorage) Init(ctx context.Context) error {
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `alter table birthdays
+        add column if not exists uid text default '',
+        add column if not exists wishlist_message_id int default 0,
+        add column if not exists wishlist_changed bool default false,
+        add column if not exists thread_id int default 0,
+        add column if not exists created_at timestamp default now(),
+        add column if not exists archived bool default false,
+        add column if not exists broken bool default false,
+        add column if not exists requested_at timestamp,
+        add column if not exists request_count int default 0,
+        add column if not exists requested_admin text default '',
+        add column if not exists code_expires_at timestamp`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `alter table invites
+        add column if not exists link text default '',
+        add column if not exists sent_at timestamp,
+        add column if not exists joined_at timestamp,
+        add column if not exists left_at timestamp,
+        add column if not exists reminded bool default false`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists collections (
+        birthday_id int primary key references birthdays(id),
+        organizer_id text,
+        target int,
+        details text,
+        message_id int default 0,
+        reminded bool default false
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists contributions (
+        id serial primary key,
+        birthday_id int references collections(birthday_id),
+        chat_id text,
+        name text,
+        amount int,
+        created_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists polls (
+        id serial primary key,
+        birthday_id int references birthdays(id),
+        chat_id text,
+        message_id int,
+        closes_at timestamp,
+        closed bool default false
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists organizers (
+        id serial primary key,
+        birthday_id int references birthdays(id),
+        uid text,
+        organizer_id text,
+        elected_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists chat_pool (
+        chat_id text primary key,
+        birthday_id int references birthdays(id),
+        added_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists admins (
+        chat_id text primary key,
+        role text,
+        added_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists token_attempts (
+        chat_id text primary key,
+        failures int default 0,
+        locked_until timestamp,
+        updated_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists applications (
+        chat_id text primary key,
+        fio text,
+        birthday date,
+        wishlist text,
+        status int default 0,
+        created_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists registration_codes (
+        code text primary key,
+        created_by text,
+        max_uses int,
+        uses int default 0,
+        expires_at timestamp,
+        revoked bool default false,
+        created_at timestamp default now()
+    )`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists wishlist_updates (
+        id serial primary key,
+        chat_id text,
+        fio text,
+        wishlist text,
+        sent bool default false
+    )`)
+	if err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -66,8 +207,8 @@ func (s *PGStorage) CreateBirthday(ctx context.Context, r *model.NotifyRequest)
 	defer tx.Rollback(ctx)
 
 	var birthdayID int
-	row := tx.QueryRow(ctx, `insert into birthdays as b (wishlist, fio, birthday) 
-    values ($1, $2, $3) returning b.id`, r.Wishlist, r.FIO, r.Birthday)
+	row := tx.QueryRow(ctx, `insert into birthdays as b (uid, wishlist, fio, birthday) 
+    values ($1, $2, $3, $4) returning b.id`, r.UID, r.Wishlist, r.FIO, r.Birthday)
 	if err = row.Scan(&birthdayID); err != nil {
 		return err
 	}
@@ -90,7 +231,7 @@ func (s *PGStorage) CreateBirthday(ctx context.Context, r *model.NotifyRequest)
 func (s *PGStorage) GetNewBirthdays(ctx context.Context) ([]BirthdayData, error) {
 	res := []BirthdayData{}
 
-	rows, err := s.p.Query(ctx, `select id, fio, birthday, wishlist from birthdays 
+	rows, err := s.p.Query(ctx, `select id, uid, fio, birthday, wishlist from birthdays 
     where code like ''`)
 	if err != nil {
 		return res, err
@@ -100,7 +241,7 @@ func (s *PGStorage) GetNewBirthdays(ctx context.Context) ([]BirthdayData, error)
 	for rows.Next() {
 		data := BirthdayData{}
 
-		if err = rows.Scan(&data.ID, &data.FIO, &data.Date, &data.Wishlist); err != nil {
+		if err = rows.Scan(&data.ID, &data.UID, &data.FIO, &data.Date, &data.Wishlist); err != nil {
 			return res, err
 		}
 
@@ -117,10 +258,35 @@ func (s *PGStorage) GetNewBirthdays(ctx context.Context) ([]BirthdayData, error)
 func (s *PGStorage) GetBirthdayByCode(ctx context.Context, code string) (BirthdayData, error) {
 	res := BirthdayData{}
 
-	row := s.p.QueryRow(ctx, `select id, fio, birthday, wishlist, chat_id from birthdays 
-    where code like $1`, code)
+	var codeExpiresAt *time.Time
+	row := s.p.QueryRow(ctx, `select id, uid, fio, birthday, wishlist, coalesce(chat_id, ''), code_expires_at from birthdays 
+    where code = $1`, code)
+
+	if err := row.Scan(&res.ID, &res.UID, &res.FIO, &res.Date, &res.Wishlist, &res.ChatID, &codeExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrBirthdayNotFound
+		}
+		return res, err
+	}
+	if codeExpiresAt != nil {
+		res.CodeExpiresAt = *codeExpiresAt
+	}
+
+	return res, nil
+}
+
+// GetBirthdayByChatID возвращает день рождения, к которому последним привязана беседа,
+// темы форума не учитываются, так как в одной супергруппе их много
+func (s *PGStorage) GetBirthdayByChatID(ctx context.Context, chatID string) (BirthdayData, error) {
+	res := BirthdayData{}
+
+	row := s.p.QueryRow(ctx, `select id, uid, fio, birthday, wishlist, chat_id, code from birthdays 
+    where chat_id = $1 and thread_id = 0 and not archived order by id desc limit 1`, chatID)
 
-	if err := row.Scan(&res.ID, &res.FIO, &res.Date, &res.Wishlist, &res.ChatID); err != nil {
+	if err := row.Scan(&res.ID, &res.UID, &res.FIO, &res.Date, &res.Wishlist, &res.ChatID, &res.Code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrBirthdayNotFound
+		}
 		return res, err
 	}
 
@@ -130,17 +296,18 @@ func (s *PGStorage) GetBirthdayByCode(ctx context.Context, code string) (Birthda
 func (s *PGStorage) GetNotSentInvites(ctx context.Context) ([]InviteData, error) {
 	res := []InviteData{}
 
-	rows, err := s.p.Query(ctx, `select i.id, b.fio, b.birthday, i.chat_id, b.invite_link 
+	rows, err := s.p.Query(ctx, `select i.id, b.fio, b.birthday, i.chat_id, b.invite_link, b.chat_id, b.thread_id, b.id, b.uid, i.link 
     from invites as i
     join birthdays as b on b.id = i.birthday_id
-    where i.status = $1 and b.invite_link is not null`, InviteNotSent)
+    where i.status = $1 and b.invite_link is not null and not b.archived and not b.broken`, InviteNotSent)
 	if err != nil {
 		return res, err
 	}
 
 	for rows.Next() {
 		invite := InviteData{}
-		if err = rows.Scan(&invite.ID, &invite.FIO, &invite.Date, &invite.ChatID, &invite.Link); err != nil {
+		if err = rows.Scan(&invite.ID, &invite.FIO, &invite.Date, &invite.ChatID, &invite.Link, &invite.GroupID, &invite.ThreadID,
+			&invite.BirthdayID, &invite.UID, &invite.PersonalLink); err != nil {
 			return res, err
 		}
 
@@ -175,6 +342,7 @@ func (s *PGStorage) SetCode(ctx context.Context, birthdayID int, code string) er
 	return nil
 }
 
+// UpdateLinkAndChatIDByCode привязывает беседу к ещё не привязанному дню рождения по коду
 func (s *PGStorage) UpdateLinkAndChatIDByCode(ctx context.Context, code string, chatID string, link string) error {
 	tx, err := s.p.Begin(ctx)
 	if err != nil {
@@ -182,7 +350,114 @@ func (s *PGStorage) UpdateLinkAndChatIDByCode(ctx context.Context, code string,
 	}
 	defer tx.Rollback(ctx)
 
-	cmd, err := tx.Exec(ctx, `update birthdays set chat_id = $1, invite_link = $2 where code = $3`, chatID, link, code)
+	cmd, err := tx.Exec(ctx, `update birthdays set chat_id = $1, invite_link = $2, thread_id = 0, broken = false 
+    where code = $3 and code != '' and chat_id is null and not archived`, chatID, link, code)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBirthdayNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetRequested запоминает, что админу отправлен запрос создать беседу
+func (s *PGStorage) SetRequested(ctx context.Context, birthdayID int, adminChatID string, codeExpiresAt time.Time) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update birthdays set requested_at = now(), request_count = request_count + 1, 
+    requested_admin = $1, code_expires_at = $2 where id = $3`, adminChatID, codeExpiresAt, birthdayID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBirthdayNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPendingBirthdays возвращает дни рождения, для которых админа попросили создать беседу, а он ещё не создал
+func (s *PGStorage) GetPendingBirthdays(ctx context.Context) ([]BirthdayData, error) {
+	res := []BirthdayData{}
+
+	rows, err := s.p.Query(ctx, `select id, uid, fio, birthday, code, requested_at, request_count, requested_admin, code_expires_at 
+    from birthdays 
+    where code != '' and chat_id is null and requested_at is not null and not archived 
+    order by birthday`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := BirthdayData{}
+
+		if err = rows.Scan(&data.ID, &data.UID, &data.FIO, &data.Date, &data.Code,
+			&data.RequestedAt, &data.RequestCount, &data.RequestedAdmin, &data.CodeExpiresAt); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// RegenCode заменяет код привязки ещё не привязанной беседы на новый
+func (s *PGStorage) RegenCode(ctx context.Context, oldCode string, newCode string, codeExpiresAt time.Time) (BirthdayData, error) {
+	res := BirthdayData{Code: newCode, CodeExpiresAt: codeExpiresAt}
+
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, `update birthdays set code = $1, code_expires_at = $2 
+    where code = $3 and code != '' and chat_id is null and not archived 
+    returning id, uid, fio, birthday`, newCode, codeExpiresAt, oldCode)
+	if err = row.Scan(&res.ID, &res.UID, &res.FIO, &res.Date); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrBirthdayNotFound
+		}
+		return res, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SetTopic привязывает день рождения к теме форума
+func (s *PGStorage) SetTopic(ctx context.Context, birthdayID int, chatID string, threadID int, link string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update birthdays set chat_id = $1, thread_id = $2, invite_link = $3 where id = $4`,
+		chatID, threadID, link, birthdayID)
 	if err != nil {
 		return err
 	}
@@ -197,6 +472,136 @@ func (s *PGStorage) UpdateLinkAndChatIDByCode(ctx context.Context, code string,
 	return nil
 }
 
+// MigrateChat переносит всё, что было привязано к группе, на супергруппу, в которую она превратилась,
+// и сбрасывает личные ссылки тех, кто ещё не зашёл, чтобы им пришли новые. Пустой link оставляет прежнюю ссылку
+func (s *PGStorage) MigrateChat(ctx context.Context, oldChatID string, newChatID string, link string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Личные ссылки старой группы больше не работают, тем, кто ещё не зашёл, приглашение отправится заново с новой ссылкой
+	_, err = tx.Exec(ctx, `update invites set link = '', status = $2, reminded = false 
+    where joined_at is null and birthday_id in (select id from birthdays where chat_id = $1 and not archived)`, oldChatID, InviteNotSent)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `update birthdays set chat_id = $2, invite_link = coalesce(nullif($3, ''), invite_link) 
+    where chat_id = $1`, oldChatID, newChatID, link)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `update polls set chat_id = $2 where chat_id = $1`, oldChatID, newChatID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `update chat_pool set chat_id = $2 where chat_id = $1`, oldChatID, newChatID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetChatBroken отмечает действующие дни рождения беседы сломанными (бот потерял в ней права) или починенными,
+// при починке непустой link заменяет ссылку-приглашение. Возвращает затронутые дни рождения
+func (s *PGStorage) SetChatBroken(ctx context.Context, chatID string, broken bool, link string) ([]BirthdayData, error) {
+	res := []BirthdayData{}
+
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `update birthdays set broken = $2, invite_link = coalesce(nullif($3, ''), invite_link) 
+    where chat_id = $1 and not archived and broken != $2 
+    returning id, uid, fio, birthday, code, thread_id`, chatID, broken, link)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := BirthdayData{ChatID: chatID}
+		if err = rows.Scan(&data.ID, &data.UID, &data.FIO, &data.Date, &data.Code, &data.ThreadID); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+	rows.Close()
+
+	if err = tx.Commit(ctx); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SetInviteLink сохраняет личную ссылку приглашения, если её ещё нет, и возвращает ту, что в итоге сохранена
+func (s *PGStorage) SetInviteLink(ctx context.Context, inviteID int, link string) (string, error) {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Ссылку, которую уже могли отправить, не перезаписываем, иначе вход по ней не сопоставится с приглашением
+	stored := link
+	row := tx.QueryRow(ctx, `update invites set link = $1 where id = $2 and link = '' returning link`, link, inviteID)
+	if err = row.Scan(&stored); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+
+		row = tx.QueryRow(ctx, `select link from invites where id = $1`, inviteID)
+		if err = row.Scan(&stored); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", ErrInviteNotFound
+			}
+			return "", err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", err
+	}
+
+	return stored, nil
+}
+
+// GetInviteByLink ищет приглашение по личной ссылке
+func (s *PGStorage) GetInviteByLink(ctx context.Context, link string) (InviteData, error) {
+	res := InviteData{}
+
+	row := s.p.QueryRow(ctx, `select i.id, b.fio, b.birthday, i.chat_id, b.invite_link, b.chat_id, b.thread_id, b.id, b.uid, i.link 
+    from invites as i
+    join birthdays as b on b.id = i.birthday_id
+    where i.link = $1 and i.link != ''`, link)
+	err := row.Scan(&res.ID, &res.FIO, &res.Date, &res.ChatID, &res.Link, &res.GroupID, &res.ThreadID,
+		&res.BirthdayID, &res.UID, &res.PersonalLink)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrInviteNotFound
+		}
+		return res, err
+	}
+
+	return res, nil
+}
+
 func (s *PGStorage) UpdateInviteStatus(ctx context.Context, inviteID int, status int) error {
 	tx, err := s.p.Begin(ctx)
 	if err != nil {
@@ -204,7 +609,7 @@ func (s *PGStorage) UpdateInviteStatus(ctx context.Context, inviteID int, status
 	}
 	defer tx.Rollback(ctx)
 
-	cmd, err := tx.Exec(ctx, `update invites set status = $1 where id = $2`, status, inviteID)
+	cmd, err := tx.Exec(ctx, `update invites set status = $1, sent_at = now() where id = $2`, status, inviteID)
 	if err != nil {
 		return err
 	}
@@ -218,3 +623,1142 @@ func (s *PGStorage) UpdateInviteStatus(ctx context.Context, inviteID int, status
 
 	return nil
 }
+
+// UpdateWishlist обновляет вишлист в последней беседе пользователя и ставит в очередь уведомления подписчикам
+func (s *PGStorage) UpdateWishlist(ctx context.Context, c *model.WishlistChange) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `update birthdays set wishlist = $1, wishlist_changed = true
+    where id = (select max(id) from birthdays where uid = $2)`, c.Wishlist, c.UID)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range c.Users {
+		_, err := tx.Exec(ctx, `insert into wishlist_updates (chat_id, fio, wishlist) 
+        values ($1, $2, $3)`, user, c.FIO, c.Wishlist)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetChangedWishlists(ctx context.Context) ([]BirthdayData, error) {
+	res := []BirthdayData{}
+
+	rows, err := s.p.Query(ctx, `select id, fio, birthday, wishlist, chat_id, wishlist_message_id, thread_id from birthdays 
+    where wishlist_changed and chat_id is not null and not archived`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := BirthdayData{}
+
+		if err = rows.Scan(&data.ID, &data.FIO, &data.Date, &data.Wishlist, &data.ChatID, &data.WishlistMessageID, &data.ThreadID); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetWishlistMessageID(ctx context.Context, birthdayID int, messageID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update birthdays set wishlist_message_id = $1, wishlist_changed = false 
+    where id = $2`, messageID, birthdayID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBirthdayNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetNotSentWishlistUpdates(ctx context.Context) ([]WishlistUpdateData, error) {
+	res := []WishlistUpdateData{}
+
+	rows, err := s.p.Query(ctx, `select id, chat_id, fio, wishlist from wishlist_updates where not sent`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		update := WishlistUpdateData{}
+		if err = rows.Scan(&update.ID, &update.ChatID, &update.FIO, &update.Wishlist); err != nil {
+			return res, err
+		}
+
+		res = append(res, update)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetWishlistUpdateSent(ctx context.Context, updateID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `update wishlist_updates set sent = true where id = $1`, updateID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetCollection создаёт сбор или меняет его цель и реквизиты, менять может только организатор
+func (s *PGStorage) SetCollection(ctx context.Context, c *CollectionData) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var organizerID string
+	row := tx.QueryRow(ctx, `insert into collections as c (birthday_id, organizer_id, target, details)
+    values ($1, $2, $3, $4)
+    on conflict (birthday_id) do update set target = excluded.target, details = excluded.details
+    where c.organizer_id = excluded.organizer_id
+    returning c.organizer_id`, c.BirthdayID, c.OrganizerID, c.Target, c.Details)
+	if err = row.Scan(&organizerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotOrganizer
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetCollection(ctx context.Context, birthdayID int) (CollectionData, error) {
+	res := CollectionData{BirthdayID: birthdayID, Contributions: []ContributionData{}}
+
+	row := s.p.QueryRow(ctx, `select b.fio, b.birthday, b.chat_id, c.organizer_id, c.target, c.details, c.message_id
+    from collections as c
+    join birthdays as b on b.id = c.birthday_id
+    where c.birthday_id = $1`, birthdayID)
+	if err := row.Scan(&res.FIO, &res.Date, &res.ChatID, &res.OrganizerID, &res.Target, &res.Details, &res.MessageID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrCollectionNotFound
+		}
+		return res, err
+	}
+
+	rows, err := s.p.Query(ctx, `select chat_id, name, sum(amount) from contributions 
+    where birthday_id = $1 
+    group by chat_id, name
+    order by min(created_at)`, birthdayID)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		contribution := ContributionData{}
+		if err = rows.Scan(&contribution.ChatID, &contribution.Name, &contribution.Amount); err != nil {
+			return res, err
+		}
+
+		res.Contributions = append(res.Contributions, contribution)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) AddContribution(ctx context.Context, birthdayID int, c ContributionData) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `insert into contributions (birthday_id, chat_id, name, amount) 
+    values ($1, $2, $3, $4)`, birthdayID, c.ChatID, c.Name, c.Amount)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// 23503 - нет такого сбора
+			if pgErr.Code == "23503" {
+				return ErrCollectionNotFound
+			}
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) SetCollectionMessageID(ctx context.Context, birthdayID int, messageID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update collections set message_id = $1 where birthday_id = $2`, messageID, birthdayID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrCollectionNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNotRemindedCollections возвращает сборы, по которым ещё не было напоминания, вместе с теми, кто не скинулся
+func (s *PGStorage) GetNotRemindedCollections(ctx context.Context) ([]CollectionData, error) {
+	res := []CollectionData{}
+
+	rows, err := s.p.Query(ctx, `select c.birthday_id, b.fio, b.birthday, b.chat_id, c.organizer_id, c.target, c.details,
+    array_remove(array_agg(i.chat_id), null)
+    from collections as c
+    join birthdays as b on b.id = c.birthday_id
+    left join invites as i on i.birthday_id = c.birthday_id 
+    and not exists (select 1 from contributions as con where con.birthday_id = c.birthday_id and con.chat_id = i.chat_id)
+    where not c.reminded
+    group by c.birthday_id, b.id`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := CollectionData{}
+		err = rows.Scan(&data.BirthdayID, &data.FIO, &data.Date, &data.ChatID, &data.OrganizerID, &data.Target, &data.Details, &data.NotContributed)
+		if err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetCollectionReminded(ctx context.Context, birthdayID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update collections set reminded = true where birthday_id = $1`, birthdayID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrCollectionNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) CreatePoll(ctx context.Context, p *PollData) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, `insert into polls as p (birthday_id, chat_id, message_id, closes_at) 
+    values ($1, $2, $3, $4) returning p.id`, p.BirthdayID, p.ChatID, p.MessageID, p.ClosesAt)
+	if err = row.Scan(&p.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetDuePolls возвращает незакрытые опросы, срок которых вышел
+func (s *PGStorage) GetDuePolls(ctx context.Context) ([]PollData, error) {
+	res := []PollData{}
+
+	rows, err := s.p.Query(ctx, `select id, birthday_id, chat_id, message_id, closes_at from polls 
+    where not closed and closes_at <= now()`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		poll := PollData{}
+		if err = rows.Scan(&poll.ID, &poll.BirthdayID, &poll.ChatID, &poll.MessageID, &poll.ClosesAt); err != nil {
+			return res, err
+		}
+
+		res = append(res, poll)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetPollClosed(ctx context.Context, pollID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `update polls set closed = true where id = $1`, pollID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetInvitees(ctx context.Context, birthdayID int) ([]string, error) {
+	res := []string{}
+
+	rows, err := s.p.Query(ctx, `select chat_id from invites where birthday_id = $1 order by id`, birthdayID)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chatID string
+		if err = rows.Scan(&chatID); err != nil {
+			return res, err
+		}
+
+		res = append(res, chatID)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// AddOrganizer записывает нового организатора дня рождения в историю и передаёт ему сбор, если он уже есть
+func (s *PGStorage) AddOrganizer(ctx context.Context, birthdayID int, organizerID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `insert into organizers (birthday_id, uid, organizer_id) 
+    select id, uid, $2 from birthdays where id = $1`, birthdayID, organizerID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBirthdayNotFound
+	}
+
+	_, err = tx.Exec(ctx, `update collections set organizer_id = $1 where birthday_id = $2`, organizerID, birthdayID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetOrganizer(ctx context.Context, birthdayID int) (string, error) {
+	var organizerID string
+
+	row := s.p.QueryRow(ctx, `select organizer_id from organizers 
+    where birthday_id = $1 order by id desc limit 1`, birthdayID)
+	if err := row.Scan(&organizerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return organizerID, ErrOrganizerNotFound
+		}
+		return organizerID, err
+	}
+
+	return organizerID, nil
+}
+
+// GetLastOrganized возвращает, когда каждый из chatIDs последний раз был организатором, кто не был - в ответ не попадает
+func (s *PGStorage) GetLastOrganized(ctx context.Context, chatIDs []string) (map[string]time.Time, error) {
+	res := map[string]time.Time{}
+
+	rows, err := s.p.Query(ctx, `select organizer_id, max(elected_at) from organizers 
+    where organizer_id = any($1)
+    group by organizer_id`, chatIDs)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chatID string
+		var electedAt time.Time
+		if err = rows.Scan(&chatID, &electedAt); err != nil {
+			return res, err
+		}
+
+		res[chatID] = electedAt
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// AddPoolChat добавляет заранее созданную беседу в пул свободных
+func (s *PGStorage) AddPoolChat(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `insert into chat_pool (chat_id) values ($1)`, chatID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// 23505 - нарушение constraint-a
+			if pgErr.Code == "23505" {
+				return ErrChatAlreadyInPool
+			}
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TakePoolChat занимает под день рождения самую давно добавленную свободную беседу из пула
+func (s *PGStorage) TakePoolChat(ctx context.Context, birthdayID int) (string, error) {
+	var chatID string
+
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return chatID, err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, `update chat_pool set birthday_id = $1 
+    where chat_id = (
+        select chat_id from chat_pool where birthday_id is null 
+        order by added_at limit 1 for update skip locked
+    ) returning chat_id`, birthdayID)
+	if err = row.Scan(&chatID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return chatID, ErrPoolEmpty
+		}
+		return chatID, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return chatID, err
+	}
+
+	return chatID, nil
+}
+
+// RemovePoolChat убирает беседу из пула, например если бот в ней больше не админ
+func (s *PGStorage) RemovePoolChat(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `delete from chat_pool where chat_id = $1`, chatID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrPoolChatNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) CountFreePoolChats(ctx context.Context) (int, error) {
+	var count int
+
+	row := s.p.QueryRow(ctx, `select count(*) from chat_pool where birthday_id is null`)
+	if err := row.Scan(&count); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// ReleasePoolChat возвращает беседу в пул свободных
+func (s *PGStorage) ReleasePoolChat(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update chat_pool set birthday_id = null, added_at = now() where chat_id = $1`, chatID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrPoolChatNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetActiveBirthdays возвращает привязанные к беседе и ещё не отправленные в архив дни рождения
+func (s *PGStorage) GetActiveBirthdays(ctx context.Context) ([]BirthdayData, error) {
+	res := []BirthdayData{}
+
+	rows, err := s.p.Query(ctx, `select id, uid, fio, birthday, chat_id, invite_link, thread_id, created_at from birthdays 
+    where chat_id is not null and invite_link is not null and not archived`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := BirthdayData{}
+
+		if err = rows.Scan(&data.ID, &data.UID, &data.FIO, &data.Date, &data.ChatID, &data.InviteLink, &data.ThreadID, &data.CreatedAt); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetBirthdayArchived(ctx context.Context, birthdayID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update birthdays set archived = true where id = $1`, birthdayID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBirthdayNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetInviteMembership отмечает, что приглашённый зашёл в беседу или вышел из неё.
+// В режиме форума беседа общая, поэтому отмечаются все действующие приглашения в неё
+func (s *PGStorage) SetInviteMembership(ctx context.Context, groupID string, chatID string, joined bool) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	column := "left_at"
+	if joined {
+		column = "joined_at"
+	}
+
+	cmd, err := tx.Exec(ctx, `update invites as i set `+column+` = now() 
+    from birthdays as b 
+    where b.id = i.birthday_id and b.chat_id = $1 and i.chat_id = $2 and not b.archived`, groupID, chatID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInviteNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNotJoinedInvites возвращает приглашения, отправленные больше after назад, по которым так никто и не зашёл
+func (s *PGStorage) GetNotJoinedInvites(ctx context.Context, after time.Duration) ([]InviteData, error) {
+	res := []InviteData{}
+
+	rows, err := s.p.Query(ctx, `select i.id, b.fio, b.birthday, i.chat_id, b.invite_link, b.chat_id, b.thread_id, b.id, b.uid, i.link 
+    from invites as i
+    join birthdays as b on b.id = i.birthday_id
+    where i.status = $1 and i.joined_at is null and not i.reminded and not b.archived and not b.broken 
+    and i.sent_at < now() - $2::interval`, InviteDone, after)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		invite := InviteData{}
+		if err = rows.Scan(&invite.ID, &invite.FIO, &invite.Date, &invite.ChatID, &invite.Link, &invite.GroupID, &invite.ThreadID,
+			&invite.BirthdayID, &invite.UID, &invite.PersonalLink); err != nil {
+			return res, err
+		}
+
+		res = append(res, invite)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetInviteReminded(ctx context.Context, inviteID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update invites set reminded = true where id = $1`, inviteID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInviteNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBirthdayInvites возвращает все приглашения дня рождения вместе с тем, кто зашёл в беседу
+func (s *PGStorage) GetBirthdayInvites(ctx context.Context, birthdayID int) ([]InviteData, error) {
+	res := []InviteData{}
+
+	rows, err := s.p.Query(ctx, `select id, chat_id, status, joined_at, left_at from invites 
+    where birthday_id = $1 order by id`, birthdayID)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		invite := InviteData{BirthdayID: birthdayID}
+		if err = rows.Scan(&invite.ID, &invite.ChatID, &invite.Status, &invite.JoinedAt, &invite.LeftAt); err != nil {
+			return res, err
+		}
+
+		res = append(res, invite)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SetOwner делает chatID владельцем, прежний владелец становится обычным админом
+func (s *PGStorage) SetOwner(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `update admins set role = $1 where role = $2 and chat_id != $3`, AdminRoleAdmin, AdminRoleOwner, chatID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `insert into admins (chat_id, role) values ($1, $2) 
+    on conflict (chat_id) do update set role = excluded.role`, chatID, AdminRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) AddAdmin(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `insert into admins (chat_id, role) values ($1, $2)`, chatID, AdminRoleAdmin)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// 23505 - нарушение constraint-a
+			if pgErr.Code == "23505" {
+				return ErrAdminAlreadyExists
+			}
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveAdmin убирает админа, владельца так убрать нельзя
+func (s *PGStorage) RemoveAdmin(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `delete from admins where chat_id = $1 and role = $2`, chatID, AdminRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrAdminNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetAdmin(ctx context.Context, chatID string) (AdminData, error) {
+	res := AdminData{}
+
+	row := s.p.QueryRow(ctx, `select chat_id, role from admins where chat_id = $1`, chatID)
+	if err := row.Scan(&res.ChatID, &res.Role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrAdminNotFound
+		}
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetAdmins возвращает всех админов, владелец первый
+func (s *PGStorage) GetAdmins(ctx context.Context) ([]AdminData, error) {
+	res := []AdminData{}
+
+	rows, err := s.p.Query(ctx, `select chat_id, role from admins 
+    order by role = $1 desc, added_at`, AdminRoleOwner)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := AdminData{}
+		if err = rows.Scan(&data.ChatID, &data.Role); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// CreateRegistrationCode сохраняет код регистрации, действующий ttl (0 - бессрочно)
+func (s *PGStorage) CreateRegistrationCode(ctx context.Context, c *RegistrationCodeData, ttl time.Duration) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	row := tx.QueryRow(ctx, `insert into registration_codes as r (code, created_by, max_uses, expires_at) 
+    values ($1, $2, $3, case when $4::bigint > 0 then now() + make_interval(secs => $4::bigint) end) 
+    returning r.expires_at`, c.Code, c.CreatedBy, c.MaxUses, int64(ttl.Seconds()))
+	if err = row.Scan(&c.ExpiresAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckRegistrationCode проверяет, что код не отозван, не истёк и не исчерпан, не засчитывая использование
+func (s *PGStorage) CheckRegistrationCode(ctx context.Context, code string) error {
+	var exists bool
+
+	row := s.p.QueryRow(ctx, `select exists(select 1 from registration_codes 
+    where code = $1 and not revoked and uses < max_uses and (expires_at is null or expires_at > now()))`, code)
+	if err := row.Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRegistrationCodeInvalid
+	}
+
+	return nil
+}
+
+// UseRegistrationCode засчитывает использование кода, если он не отозван, не истёк и не исчерпан
+func (s *PGStorage) UseRegistrationCode(ctx context.Context, code string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update registration_codes set uses = uses + 1 
+    where code = $1 and not revoked and uses < max_uses and (expires_at is null or expires_at > now())`, code)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrRegistrationCodeInvalid
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) RevokeRegistrationCode(ctx context.Context, code string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update registration_codes set revoked = true where code = $1 and not revoked`, code)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrRegistrationCodeNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetActiveRegistrationCodes возвращает коды, по которым ещё можно зарегистрироваться
+func (s *PGStorage) GetActiveRegistrationCodes(ctx context.Context) ([]RegistrationCodeData, error) {
+	res := []RegistrationCodeData{}
+
+	rows, err := s.p.Query(ctx, `select code, created_by, max_uses, uses, expires_at from registration_codes 
+    where not revoked and uses < max_uses and (expires_at is null or expires_at > now()) 
+    order by created_at`)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := RegistrationCodeData{}
+		if err = rows.Scan(&data.Code, &data.CreatedBy, &data.MaxUses, &data.Uses, &data.ExpiresAt); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// SaveApplication сохраняет заявку на регистрацию, повторная заявка того же человека заменяет прежнюю,
+// пока её не рассмотрели. Рассмотренную заявку не трогает и возвращает ErrApplicationDecided
+func (s *PGStorage) SaveApplication(ctx context.Context, a ApplicationData) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `insert into applications (chat_id, fio, birthday, wishlist, status) values ($1, $2, $3, $4, $5) 
+    on conflict (chat_id) do update set fio = excluded.fio, birthday = excluded.birthday, wishlist = excluded.wishlist, 
+    created_at = now() where applications.status = excluded.status`, a.ChatID, a.FIO, a.Birthday, a.Wishlist, ApplicationPending)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrApplicationDecided
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetApplication(ctx context.Context, chatID string) (ApplicationData, error) {
+	res := ApplicationData{}
+
+	row := s.p.QueryRow(ctx, `select chat_id, fio, birthday, wishlist, status from applications where chat_id = $1`, chatID)
+	if err := row.Scan(&res.ChatID, &res.FIO, &res.Birthday, &res.Wishlist, &res.Status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, ErrApplicationNotFound
+		}
+		return res, err
+	}
+
+	return res, nil
+}
+
+// DecideApplication одобряет или отклоняет заявку, решить можно только ещё не рассмотренную
+func (s *PGStorage) DecideApplication(ctx context.Context, chatID string, status int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update applications set status = $1 where chat_id = $2 and status = $3`, status, chatID, ApplicationPending)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrApplicationNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReopenApplication возвращает рассмотренную заявку на рассмотрение, например если одобренного не получилось зарегистрировать
+func (s *PGStorage) ReopenApplication(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update applications set status = $1 where chat_id = $2`, ApplicationPending, chatID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrApplicationNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) GetPendingApplications(ctx context.Context) ([]ApplicationData, error) {
+	res := []ApplicationData{}
+
+	rows, err := s.p.Query(ctx, `select chat_id, fio, birthday, wishlist, status from applications 
+    where status = $1 order by created_at`, ApplicationPending)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		data := ApplicationData{}
+		if err = rows.Scan(&data.ChatID, &data.FIO, &data.Birthday, &data.Wishlist, &data.Status); err != nil {
+			return res, err
+		}
+
+		res = append(res, data)
+	}
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetTokenLock сколько ещё заблокирован ввод токена в чате, 0 если не заблокирован
+func (s *PGStorage) GetTokenLock(ctx context.Context, chatID string) (time.Duration, error) {
+	var seconds int64
+
+	row := s.p.QueryRow(ctx, `select coalesce(ceil(extract(epoch from max(locked_until) - now())), 0)::bigint 
+    from token_attempts where chat_id = $1`, chatID)
+	if err := row.Scan(&seconds); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(max(seconds, 0)) * time.Second, nil
+}
+
+// AddTokenFailure засчитывает неверный токен и возвращает, сколько их было подряд.
+// Счёт начинается заново, если неудачных попыток не было сутки
+func (s *PGStorage) AddTokenFailure(ctx context.Context, chatID string) (int, error) {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var failures int
+	row := tx.QueryRow(ctx, `insert into token_attempts as t (chat_id, failures) values ($1, 1) 
+    on conflict (chat_id) do update set 
+    failures = case when t.updated_at < now() - interval '1 day' then 1 else t.failures + 1 end, updated_at = now() 
+    returning t.failures`, chatID)
+	if err = row.Scan(&failures); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (s *PGStorage) SetTokenLock(ctx context.Context, chatID string, lock time.Duration) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `update token_attempts set locked_until = now() + make_interval(secs => $1::bigint) 
+    where chat_id = $2`, int64(lock.Seconds()), chatID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) ResetTokenFailures(ctx context.Context, chatID string) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `delete from token_attempts where chat_id = $1`, chatID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
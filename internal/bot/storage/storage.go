@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/smakimka/balb/internal/model"
@@ -10,6 +11,18 @@ import (
 
 var ErrBirthdayNotFound = errors.New("birthday not found")
 var ErrInviteNotFound = errors.New("invite not found")
+var ErrCollectionNotFound = errors.New("collection not found")
+var ErrNotOrganizer = errors.New("not organizer")
+var ErrOrganizerNotFound = errors.New("organizer not found")
+var ErrChatAlreadyInPool = errors.New("chat already in pool")
+var ErrPoolEmpty = errors.New("chat pool is empty")
+var ErrPoolChatNotFound = errors.New("pool chat not found")
+var ErrAdminAlreadyExists = errors.New("admin already exists")
+var ErrAdminNotFound = errors.New("admin not found")
+var ErrRegistrationCodeInvalid = errors.New("registration code invalid")
+var ErrRegistrationCodeNotFound = errors.New("registration code not found")
+var ErrApplicationNotFound = errors.New("application not found")
+var ErrApplicationDecided = errors.New("application already decided")
 
 const (
 	InviteNotSent   = iota
@@ -17,14 +30,90 @@ const (
 	InviteCancelled = iota
 )
 
+// Статусы заявок на регистрацию в режиме одобрения админом
+const (
+	ApplicationPending  = iota
+	ApplicationApproved = iota
+	ApplicationRejected = iota
+)
+
+// ApplicationData заявка на регистрацию, ждущая решения админа
+type ApplicationData struct {
+	ChatID   string
+	FIO      string
+	Birthday time.Time
+	Wishlist string
+	Status   int
+}
+
+// Роли админов: владелец управляет списком админов, админы создают беседы
+const (
+	AdminRoleOwner = "owner"
+	AdminRoleAdmin = "admin"
+)
+
+type AdminData struct {
+	ChatID string
+	Role   string
+}
+
+// RegistrationCodeData код из ссылки-приглашения на регистрацию, ExpiresAt nil если бессрочный
+type RegistrationCodeData struct {
+	Code      string
+	CreatedBy string
+	MaxUses   int
+	Uses      int
+	ExpiresAt *time.Time
+}
+
 type BirthdayData struct {
-	ID         int
-	Date       time.Time
-	FIO        string
-	Wishlist   string
-	ChatID     string
-	Code       string
-	InviteLink string
+	ID                int
+	UID               string
+	Date              time.Time
+	FIO               string
+	Wishlist          string
+	ChatID            string
+	Code              string
+	InviteLink        string
+	WishlistMessageID int
+	// ThreadID тема форума, если день рождения живёт в общей супергруппе, а не в отдельной беседе
+	ThreadID  int
+	CreatedAt time.Time
+	// Запрос админу создать беседу: когда и кому последний раз отправлен, сколько раз и до какого времени действует код
+	RequestedAt    time.Time
+	RequestCount   int
+	RequestedAdmin string
+	CodeExpiresAt  time.Time
+}
+
+// CodeExpiry до какого времени действует код, выданный в now, при ttl 0 код бессрочный
+func CodeExpiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(ttl)
+}
+
+// CodeExpired истёк ли код привязки беседы, которая ещё не привязана
+func (b BirthdayData) CodeExpired(now time.Time) bool {
+	return b.ChatID == "" && !b.CodeExpiresAt.IsZero() && now.After(b.CodeExpiresAt)
+}
+
+// Next ближайший (сегодня или позже) день рождения после now
+func (b BirthdayData) Next(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	next := time.Date(now.Year(), b.Date.Month(), b.Date.Day(), 0, 0, 0, 0, time.UTC)
+	if next.Before(today) {
+		next = next.AddDate(1, 0, 0)
+	}
+
+	return next
+}
+
+// WishlistText текст закреплённого в беседе сообщения с вишлистом
+func (b BirthdayData) WishlistText() string {
+	return fmt.Sprintf("Это беседа дня рождения %s (%s) wishlist:\n%s", b.FIO, b.Date.Format("02.01"), b.Wishlist)
 }
 
 type InviteData struct {
@@ -33,14 +122,127 @@ type InviteData struct {
 	FIO    string
 	ChatID string
 	Link   string
+	// GroupID и ThreadID беседа и тема форума дня рождения, ThreadID 0 если это отдельная беседа
+	GroupID  string
+	ThreadID int
+	// BirthdayID и UID день рождения и именинник
+	BirthdayID int
+	UID        string
+	// PersonalLink личная ссылка-приглашение, пусто если ещё не создана
+	PersonalLink string
+	Status       int
+	// JoinedAt и LeftAt когда приглашённый последний раз зашёл в беседу и вышел из неё, nil если не было
+	JoinedAt *time.Time
+	LeftAt   *time.Time
+}
+
+type WishlistUpdateData struct {
+	ID       int
+	ChatID   string
+	FIO      string
+	Wishlist string
+}
+
+type ContributionData struct {
+	ChatID string
+	Name   string
+	Amount int
+}
+
+type CollectionData struct {
+	BirthdayID    int
+	FIO           string
+	Date          time.Time
+	ChatID        string
+	OrganizerID   string
+	Target        int
+	Details       string
+	MessageID     int
+	Contributions []ContributionData
+	// NotContributed приглашённые, которые ещё не скинулись
+	NotContributed []string
+}
+
+func (c CollectionData) Collected() int {
+	sum := 0
+	for _, contribution := range c.Contributions {
+		sum += contribution.Amount
+	}
+
+	return sum
+}
+
+type PollData struct {
+	ID         int
+	BirthdayID int
+	ChatID     string
+	MessageID  int
+	ClosesAt   time.Time
 }
 
 type Storage interface {
+	SetOwner(ctx context.Context, chatID string) error
+	AddAdmin(ctx context.Context, chatID string) error
+	RemoveAdmin(ctx context.Context, chatID string) error
+	GetAdmin(ctx context.Context, chatID string) (AdminData, error)
+	GetAdmins(ctx context.Context) ([]AdminData, error)
+	CreateRegistrationCode(ctx context.Context, c *RegistrationCodeData, ttl time.Duration) error
+	CheckRegistrationCode(ctx context.Context, code string) error
+	UseRegistrationCode(ctx context.Context, code string) error
+	RevokeRegistrationCode(ctx context.Context, code string) error
+	GetActiveRegistrationCodes(ctx context.Context) ([]RegistrationCodeData, error)
+	GetTokenLock(ctx context.Context, chatID string) (time.Duration, error)
+	AddTokenFailure(ctx context.Context, chatID string) (int, error)
+	SetTokenLock(ctx context.Context, chatID string, lock time.Duration) error
+	ResetTokenFailures(ctx context.Context, chatID string) error
+	SaveApplication(ctx context.Context, a ApplicationData) error
+	GetApplication(ctx context.Context, chatID string) (ApplicationData, error)
+	DecideApplication(ctx context.Context, chatID string, status int) error
+	ReopenApplication(ctx context.Context, chatID string) error
+	GetPendingApplications(ctx context.Context) ([]ApplicationData, error)
 	UpdateInviteStatus(ctx context.Context, inviteID int, status int) error
+	SetInviteLink(ctx context.Context, inviteID int, link string) (string, error)
+	GetInviteByLink(ctx context.Context, link string) (InviteData, error)
+	SetInviteMembership(ctx context.Context, groupID string, chatID string, joined bool) error
+	GetNotJoinedInvites(ctx context.Context, after time.Duration) ([]InviteData, error)
+	SetInviteReminded(ctx context.Context, inviteID int) error
+	GetBirthdayInvites(ctx context.Context, birthdayID int) ([]InviteData, error)
 	UpdateLinkAndChatIDByCode(ctx context.Context, code string, chatID string, link string) error
+	SetTopic(ctx context.Context, birthdayID int, chatID string, threadID int, link string) error
+	MigrateChat(ctx context.Context, oldChatID string, newChatID string, link string) error
+	SetChatBroken(ctx context.Context, chatID string, broken bool, link string) ([]BirthdayData, error)
 	GetNewBirthdays(ctx context.Context) ([]BirthdayData, error)
 	GetBirthdayByCode(ctx context.Context, code string) (BirthdayData, error)
+	GetBirthdayByChatID(ctx context.Context, chatID string) (BirthdayData, error)
 	GetNotSentInvites(ctx context.Context) ([]InviteData, error)
 	CreateBirthday(ctx context.Context, r *model.NotifyRequest) error
 	SetCode(ctx context.Context, birthdayID int, code string) error
+	SetRequested(ctx context.Context, birthdayID int, adminChatID string, codeExpiresAt time.Time) error
+	GetPendingBirthdays(ctx context.Context) ([]BirthdayData, error)
+	RegenCode(ctx context.Context, oldCode string, newCode string, codeExpiresAt time.Time) (BirthdayData, error)
+	UpdateWishlist(ctx context.Context, c *model.WishlistChange) error
+	GetChangedWishlists(ctx context.Context) ([]BirthdayData, error)
+	SetWishlistMessageID(ctx context.Context, birthdayID int, messageID int) error
+	GetNotSentWishlistUpdates(ctx context.Context) ([]WishlistUpdateData, error)
+	SetWishlistUpdateSent(ctx context.Context, updateID int) error
+	SetCollection(ctx context.Context, c *CollectionData) error
+	GetCollection(ctx context.Context, birthdayID int) (CollectionData, error)
+	AddContribution(ctx context.Context, birthdayID int, c ContributionData) error
+	SetCollectionMessageID(ctx context.Context, birthdayID int, messageID int) error
+	GetNotRemindedCollections(ctx context.Context) ([]CollectionData, error)
+	SetCollectionReminded(ctx context.Context, birthdayID int) error
+	CreatePoll(ctx context.Context, p *PollData) error
+	GetDuePolls(ctx context.Context) ([]PollData, error)
+	SetPollClosed(ctx context.Context, pollID int) error
+	GetInvitees(ctx context.Context, birthdayID int) ([]string, error)
+	AddOrganizer(ctx context.Context, birthdayID int, organizerID string) error
+	GetOrganizer(ctx context.Context, birthdayID int) (string, error)
+	GetLastOrganized(ctx context.Context, chatIDs []string) (map[string]time.Time, error)
+	AddPoolChat(ctx context.Context, chatID string) error
+	TakePoolChat(ctx context.Context, birthdayID int) (string, error)
+	RemovePoolChat(ctx context.Context, chatID string) error
+	CountFreePoolChats(ctx context.Context) (int, error)
+	ReleasePoolChat(ctx context.Context, chatID string) error
+	GetActiveBirthdays(ctx context.Context) ([]BirthdayData, error)
+	SetBirthdayArchived(ctx context.Context, birthdayID int) error
 }
@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/smakimka/balb/internal/bot/storage"
+)
+
+func TestCodeExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Time
+	}{
+		{name: "ttl", ttl: 72 * time.Hour, want: time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)},
+		{name: "no ttl", ttl: 0, want: time.Time{}},
+		{name: "negative ttl", ttl: -time.Hour, want: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, storage.CodeExpiry(now, tt.ttl))
+		})
+	}
+}
+
+func TestCodeExpired(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		birthday storage.BirthdayData
+		want     bool
+	}{
+		{name: "expired", birthday: storage.BirthdayData{CodeExpiresAt: now.Add(-time.Minute)}, want: true},
+		{name: "not yet", birthday: storage.BirthdayData{CodeExpiresAt: now.Add(time.Minute)}, want: false},
+		{name: "exactly now", birthday: storage.BirthdayData{CodeExpiresAt: now}, want: false},
+		{name: "never expires", birthday: storage.BirthdayData{}, want: false},
+		{name: "chat already bound", birthday: storage.BirthdayData{ChatID: "-200", CodeExpiresAt: now.Add(-time.Minute)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.birthday.CodeExpired(now))
+		})
+	}
+}
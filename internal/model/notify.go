@@ -8,6 +8,7 @@ import (
 type NotifyRequest struct {
 	ID       int
 	Front    int
+	UID      string    `json:"uid"`
 	Users    []string  `json:"users"`
 	FIO      string    `json:"fio"`
 	Birthday time.Time `json:"birthday"`
@@ -19,3 +19,13 @@ func (d *SubscriptionData) Bind(r *http.Request) error {
 
 	return nil
 }
+
+// WishlistUpdatesData включает или выключает уведомления подписчика об изменениях вишлиста
+type WishlistUpdatesData struct {
+	SubscriptionData
+	Enabled bool `json:"enabled"`
+}
+
+func (d *WishlistUpdatesData) Bind(r *http.Request) error {
+	return d.SubscriptionData.Bind(r)
+}
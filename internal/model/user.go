@@ -8,6 +8,8 @@ import (
 
 var ErrMissingFields = errors.New("missing fields")
 var ErrWrongFront = errors.New("wong front")
+var ErrWrongPriority = errors.New("wrong priority")
+var ErrWrongPrice = errors.New("wrong price")
 
 type User struct {
 	ID       int
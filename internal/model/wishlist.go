@@ -0,0 +1,83 @@
+package model
+
+import "net/http"
+
+// WishlistChange уведомление фронту о том, что пользователь изменил вишлист
+type WishlistChange struct {
+	ID       int
+	Front    int      `json:"front"`
+	UID      string   `json:"uid"`
+	FIO      string   `json:"fio"`
+	Wishlist string   `json:"wishlist"`
+	Users    []string `json:"users"`
+}
+
+func (c *WishlistChange) Bind(r *http.Request) error {
+	if c.UID == "" {
+		return ErrMissingFields
+	}
+
+	return nil
+}
+
+const (
+	PriorityHigh   = 1
+	PriorityMedium = 2
+	PriorityLow    = 3
+)
+
+// WishlistItem один подарок из вишлиста, ClaimedBy - uid того, кто его покупает
+type WishlistItem struct {
+	ID        int    `json:"id"`
+	Front     int    `json:"front"`
+	UserUID   string `json:"user_uid"`
+	Title     string `json:"title"`
+	URL       string `json:"url,omitempty"`
+	PriceFrom int    `json:"price_from,omitempty"`
+	PriceTo   int    `json:"price_to,omitempty"`
+	Priority  int    `json:"priority"`
+	ClaimedBy string `json:"claimed_by,omitempty"`
+}
+
+func (i *WishlistItem) Bind(r *http.Request) error {
+	if i.UserUID == "" || i.Title == "" {
+		return ErrMissingFields
+	}
+
+	if i.Front < TelegramFront || i.Front > TelegramFront {
+		return ErrWrongFront
+	}
+
+	if i.Priority == 0 {
+		i.Priority = PriorityMedium
+	}
+
+	if i.Priority < PriorityHigh || i.Priority > PriorityLow {
+		return ErrWrongPriority
+	}
+
+	if i.PriceFrom < 0 || i.PriceTo < 0 || (i.PriceTo != 0 && i.PriceFrom > i.PriceTo) {
+		return ErrWrongPrice
+	}
+
+	return nil
+}
+
+// ItemAction действие пользователя UID с подарком из вишлиста: занять, освободить или удалить свой
+type ItemAction struct {
+	Front  int    `json:"front"`
+	ItemID int    `json:"item_id"`
+	UID    string `json:"uid"`
+}
+
+func (d *ItemAction) Bind(r *http.Request) error {
+	if d.ItemID == 0 || d.UID == "" {
+		return ErrMissingFields
+	}
+
+	if d.Front < TelegramFront || d.Front > TelegramFront {
+		return ErrWrongFront
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type AddWishlistItemHandler struct {
+	s storage.Storage
+}
+
+func NewAddWishlistItemHandler(s storage.Storage) AddWishlistItemHandler {
+	return AddWishlistItemHandler{s: s}
+}
+
+func (h AddWishlistItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.WishlistItem{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	id, err := h.s.AddWishlistItem(r.Context(), data)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "user not found"})
+			return
+		}
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+	data.ID = id
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, data)
+}
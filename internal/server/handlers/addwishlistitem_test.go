@@ -0,0 +1,284 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/handlers"
+	"github.com/smakimka/balb/internal/server/storage"
+	mock_storage "github.com/smakimka/balb/internal/server/storage/mock"
+)
+
+func TestAddWishlistItem(t *testing.T) {
+	type want struct {
+		contentType string
+		code        int
+		response    model.Response
+		item        model.WishlistItem
+	}
+	type mock struct {
+		expect     bool
+		expectItem model.WishlistItem
+		returnID   int
+		returnErr  error
+	}
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        model.WishlistItem
+		mock        mock
+		want        want
+	}{
+		{
+			name:        "happy path",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:     model.TelegramFront,
+				UserUID:   "test_user",
+				Title:     "book",
+				URL:       "https://example.com/book",
+				PriceFrom: 1000,
+				PriceTo:   2000,
+				Priority:  model.PriorityHigh,
+			},
+			mock: mock{
+				expect: true,
+				expectItem: model.WishlistItem{
+					Front:     model.TelegramFront,
+					UserUID:   "test_user",
+					Title:     "book",
+					URL:       "https://example.com/book",
+					PriceFrom: 1000,
+					PriceTo:   2000,
+					Priority:  model.PriorityHigh,
+				},
+				returnID:  1,
+				returnErr: nil,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusOK,
+				item: model.WishlistItem{
+					ID:        1,
+					Front:     model.TelegramFront,
+					UserUID:   "test_user",
+					Title:     "book",
+					URL:       "https://example.com/book",
+					PriceFrom: 1000,
+					PriceTo:   2000,
+					Priority:  model.PriorityHigh,
+				},
+			},
+		},
+		{
+			name:        "default priority",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:   model.TelegramFront,
+				UserUID: "test_user",
+				Title:   "socks",
+			},
+			mock: mock{
+				expect: true,
+				expectItem: model.WishlistItem{
+					Front:    model.TelegramFront,
+					UserUID:  "test_user",
+					Title:    "socks",
+					Priority: model.PriorityMedium,
+				},
+				returnID:  2,
+				returnErr: nil,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusOK,
+				item: model.WishlistItem{
+					ID:       2,
+					Front:    model.TelegramFront,
+					UserUID:  "test_user",
+					Title:    "socks",
+					Priority: model.PriorityMedium,
+				},
+			},
+		},
+		{
+			name:        "user not found",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:    model.TelegramFront,
+				UserUID:  "test_user",
+				Title:    "socks",
+				Priority: model.PriorityLow,
+			},
+			mock: mock{
+				expect: true,
+				expectItem: model.WishlistItem{
+					Front:    model.TelegramFront,
+					UserUID:  "test_user",
+					Title:    "socks",
+					Priority: model.PriorityLow,
+				},
+				returnErr: storage.ErrUserNotFound,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusNotFound,
+				response:    model.Response{Msg: "user not found"},
+			},
+		},
+		{
+			name:        "empty title",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:   model.TelegramFront,
+				UserUID: "test_user",
+			},
+			mock: mock{
+				expect: false,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				response:    model.Response{Msg: "wrong json"},
+			},
+		},
+		{
+			name:        "wrong priority",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:    model.TelegramFront,
+				UserUID:  "test_user",
+				Title:    "socks",
+				Priority: 10,
+			},
+			mock: mock{
+				expect: false,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				response:    model.Response{Msg: "wrong json"},
+			},
+		},
+		{
+			name:        "wrong price range",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:     model.TelegramFront,
+				UserUID:   "test_user",
+				Title:     "socks",
+				PriceFrom: 2000,
+				PriceTo:   1000,
+			},
+			mock: mock{
+				expect: false,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				response:    model.Response{Msg: "wrong json"},
+			},
+		},
+		{
+			name:        "sql error",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.WishlistItem{
+				Front:    model.TelegramFront,
+				UserUID:  "test_user",
+				Title:    "socks",
+				Priority: model.PriorityLow,
+			},
+			mock: mock{
+				expect: true,
+				expectItem: model.WishlistItem{
+					Front:    model.TelegramFront,
+					UserUID:  "test_user",
+					Title:    "socks",
+					Priority: model.PriorityLow,
+				},
+				returnErr: errors.New("postgres err"),
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusInternalServerError,
+				response:    model.Response{Msg: "internal server error"},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	m := mock_storage.NewMockStorage(ctrl)
+
+	ts := httptest.NewServer(getTestAddWishlistItemRouter(m))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.mock.expect {
+				m.EXPECT().AddWishlistItem(gomock.Any(), gomock.Eq(&test.mock.expectItem)).Times(1).Return(test.mock.returnID, test.mock.returnErr)
+			} else {
+				m.EXPECT().AddWishlistItem(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			reqBody, err := json.Marshal(test.body)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(test.method, ts.URL, bytes.NewReader(reqBody))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-type", test.contentType)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			var respResponse model.Response
+			var respItem model.WishlistItem
+			if test.want.response.Msg != "" {
+				err = json.Unmarshal(respBody, &respResponse)
+				require.NoError(t, err)
+			} else {
+				err = json.Unmarshal(respBody, &respItem)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want.code, resp.StatusCode)
+			assert.Equal(t, test.want.contentType, resp.Header.Get("Content-Type"))
+			if test.want.response.Msg != "" {
+				assert.Equal(t, test.want.response, respResponse)
+			} else {
+				assert.Equal(t, test.want.item, respItem)
+			}
+		})
+	}
+}
+
+func getTestAddWishlistItemRouter(s storage.Storage) chi.Router {
+	addWishlistItemHandler := handlers.NewAddWishlistItemHandler(s)
+
+	r := chi.NewRouter()
+	r.Post("/", addWishlistItemHandler.ServeHTTP)
+
+	return r
+}
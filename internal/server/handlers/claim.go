@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type ClaimHandler struct {
+	s storage.Storage
+}
+
+func NewClaimHandler(s storage.Storage) ClaimHandler {
+	return ClaimHandler{s: s}
+}
+
+func (h ClaimHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.ItemAction{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	err := h.s.ClaimWishlistItem(r.Context(), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrItemNotFound):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "item not found"})
+		case errors.Is(err, storage.ErrUserNotFound):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "user not found"})
+		case errors.Is(err, storage.ErrItemAlreadyClaimed):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, model.Response{Msg: "item already claimed"})
+		case errors.Is(err, storage.ErrOwnItem):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, model.Response{Msg: "own item"})
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, model.Response{Msg: "internal server error"})
+		}
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.Response{})
+}
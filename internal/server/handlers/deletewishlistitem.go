@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type DeleteWishlistItemHandler struct {
+	s storage.Storage
+}
+
+func NewDeleteWishlistItemHandler(s storage.Storage) DeleteWishlistItemHandler {
+	return DeleteWishlistItemHandler{s: s}
+}
+
+func (h DeleteWishlistItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.ItemAction{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	err := h.s.DeleteWishlistItem(r.Context(), data)
+	if err != nil {
+		if errors.Is(err, storage.ErrItemNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "item not found"})
+			return
+		}
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.Response{})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type GetWishlistHandler struct {
+	s storage.Storage
+}
+
+func NewGetWishlistHandler(s storage.Storage) GetWishlistHandler {
+	return GetWishlistHandler{s: s}
+}
+
+// ServeHTTP отдаёт подарки из вишлиста, если смотрит сам именинник (?viewer=<uid>), кто что покупает не показывается
+func (h GetWishlistHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userUID := chi.URLParam(r, "userUID")
+	front := chi.URLParam(r, "front")
+	viewer := r.URL.Query().Get("viewer")
+
+	if userUID == "" || front == "" || viewer == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong data"})
+		return
+	}
+
+	frontInt, err := strconv.Atoi(front)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong data"})
+		return
+	}
+
+	if frontInt < model.TelegramFront || frontInt > model.TelegramFront {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong data"})
+		return
+	}
+
+	items, err := h.s.GetWishlistItems(r.Context(), frontInt, userUID)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+
+	if viewer == userUID {
+		for i := range items {
+			items[i].ClaimedBy = ""
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, items)
+}
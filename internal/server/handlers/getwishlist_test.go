@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/handlers"
+	"github.com/smakimka/balb/internal/server/storage"
+	mock_storage "github.com/smakimka/balb/internal/server/storage/mock"
+)
+
+func TestGetWishlist(t *testing.T) {
+	items := func() []model.WishlistItem {
+		return []model.WishlistItem{
+			{
+				ID:        1,
+				Front:     model.TelegramFront,
+				UserUID:   "test_user",
+				Title:     "book",
+				Priority:  model.PriorityHigh,
+				ClaimedBy: "test_user_2",
+			},
+			{
+				ID:       2,
+				Front:    model.TelegramFront,
+				UserUID:  "test_user",
+				Title:    "socks",
+				Priority: model.PriorityLow,
+			},
+		}
+	}
+
+	type want struct {
+		contentType string
+		code        int
+		response    model.Response
+		items       []model.WishlistItem
+	}
+	type mock struct {
+		expect      bool
+		returnItems []model.WishlistItem
+		returnErr   error
+	}
+	tests := []struct {
+		name    string
+		method  string
+		front   int
+		userUID string
+		viewer  string
+		mock    mock
+		want    want
+	}{
+		{
+			name:    "happy path",
+			method:  http.MethodGet,
+			front:   model.TelegramFront,
+			userUID: "test_user",
+			viewer:  "test_user_3",
+			mock: mock{
+				expect:      true,
+				returnItems: items(),
+				returnErr:   nil,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusOK,
+				response:    model.Response{},
+				items:       items(),
+			},
+		},
+		{
+			name:    "owner doesn't see claims",
+			method:  http.MethodGet,
+			front:   model.TelegramFront,
+			userUID: "test_user",
+			viewer:  "test_user",
+			mock: mock{
+				expect:      true,
+				returnItems: items(),
+				returnErr:   nil,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusOK,
+				response:    model.Response{},
+				items: []model.WishlistItem{
+					{
+						ID:       1,
+						Front:    model.TelegramFront,
+						UserUID:  "test_user",
+						Title:    "book",
+						Priority: model.PriorityHigh,
+					},
+					{
+						ID:       2,
+						Front:    model.TelegramFront,
+						UserUID:  "test_user",
+						Title:    "socks",
+						Priority: model.PriorityLow,
+					},
+				},
+			},
+		},
+		{
+			name:    "no viewer",
+			method:  http.MethodGet,
+			front:   model.TelegramFront,
+			userUID: "test_user",
+			viewer:  "",
+			mock: mock{
+				expect: false,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				response:    model.Response{Msg: "wrong data"},
+			},
+		},
+		{
+			name:    "wrong front",
+			method:  http.MethodGet,
+			front:   model.TelegramFront + 1,
+			userUID: "test_user",
+			viewer:  "test_user_3",
+			mock: mock{
+				expect: false,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				response:    model.Response{Msg: "wrong data"},
+			},
+		},
+		{
+			name:    "storage error",
+			method:  http.MethodGet,
+			front:   model.TelegramFront,
+			userUID: "test_user",
+			viewer:  "test_user_3",
+			mock: mock{
+				expect:    true,
+				returnErr: errors.New("some storage error"),
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusInternalServerError,
+				response:    model.Response{Msg: "internal server error"},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	m := mock_storage.NewMockStorage(ctrl)
+
+	ts := httptest.NewServer(getTestGetWishlistRouter(m))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.mock.expect {
+				m.EXPECT().GetWishlistItems(gomock.Any(), gomock.Eq(test.front), gomock.Eq(test.userUID)).Times(1).Return(test.mock.returnItems, test.mock.returnErr)
+			} else {
+				m.EXPECT().GetWishlistItems(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			req, err := http.NewRequest(test.method, fmt.Sprintf("%s/%d/%s?viewer=%s", ts.URL, test.front, test.userUID, test.viewer), nil)
+			require.NoError(t, err)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			var respResponse model.Response
+			var respItems []model.WishlistItem
+			if test.want.response.Msg != "" {
+				err = json.Unmarshal(respBody, &respResponse)
+				require.NoError(t, err)
+			} else {
+				err = json.Unmarshal(respBody, &respItems)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want.code, resp.StatusCode)
+			assert.Equal(t, test.want.contentType, resp.Header.Get("Content-Type"))
+			if test.want.response.Msg != "" {
+				assert.Equal(t, test.want.response, respResponse)
+			} else {
+				assert.Equal(t, test.want.items, respItems)
+			}
+		})
+	}
+}
+
+func getTestGetWishlistRouter(s storage.Storage) chi.Router {
+	getWishlistHandler := handlers.NewGetWishlistHandler(s)
+
+	r := chi.NewRouter()
+	r.Get("/{front}/{userUID}", getWishlistHandler.ServeHTTP)
+
+	return r
+}
@@ -26,6 +26,12 @@ func (h SubscribeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if data.SubscriberUID == data.UserUID {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "self subscription"})
+		return
+	}
+
 	err := h.s.Subscribe(r.Context(), data)
 	if err != nil {
 		if errors.Is(err, storage.ErrSubscriptionAlreadyExists) {
@@ -76,6 +76,25 @@ func TestSubscribe(t *testing.T) {
 				body:        model.Response{Msg: "subscription already exists"},
 			},
 		},
+		{
+			name:        "self subscription",
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body: model.SubscriptionData{
+				Front:         model.TelegramFront,
+				SubscriberUID: "test_user_1",
+				UserUID:       "test_user_1",
+			},
+			mock: mock{
+				expect:    false,
+				returnErr: nil,
+			},
+			want: want{
+				contentType: "application/json",
+				code:        http.StatusBadRequest,
+				body:        model.Response{Msg: "self subscription"},
+			},
+		},
 		{
 			name:        "wrong content type",
 			method:      http.MethodPost,
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type UpdateUserHandler struct {
+	s storage.Storage
+}
+
+func NewUpdateUserHandler(s storage.Storage) UpdateUserHandler {
+	return UpdateUserHandler{s: s}
+}
+
+func (h UpdateUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.User{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	err := h.s.UpdateUser(r.Context(), data)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "user not found"})
+			return
+		}
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.Response{})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/smakimka/balb/internal/model"
+	"github.com/smakimka/balb/internal/server/storage"
+)
+
+type WishlistUpdatesHandler struct {
+	s storage.Storage
+}
+
+func NewWishlistUpdatesHandler(s storage.Storage) WishlistUpdatesHandler {
+	return WishlistUpdatesHandler{s: s}
+}
+
+func (h WishlistUpdatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := &model.WishlistUpdatesData{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: "wrong json"})
+		return
+	}
+
+	err := h.s.SetWishlistUpdates(r.Context(), data)
+	if err != nil {
+		if errors.Is(err, storage.ErrSubscriptionNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, model.Response{Msg: "subscription not found"})
+			return
+		}
+
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, model.Response{Msg: "internal server error"})
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.Response{})
+}
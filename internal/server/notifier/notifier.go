@@ -17,10 +17,11 @@ type Notifier struct {
 	c                  http.Client
 	s                  storage.Storage
 	daysBeforeBirthday int
+	wishlistInterval   time.Duration
 }
 
-func New(c http.Client, s storage.Storage, daysBeforeBirthday int) *Notifier {
-	return &Notifier{c: c, s: s, daysBeforeBirthday: daysBeforeBirthday}
+func New(c http.Client, s storage.Storage, daysBeforeBirthday int, wishlistInterval time.Duration) *Notifier {
+	return &Notifier{c: c, s: s, daysBeforeBirthday: daysBeforeBirthday, wishlistInterval: wishlistInterval}
 }
 
 // Run Тикеры или не тикеры, а что-то лучше должны срабатывать один раз в день, например в 9 часов, но для теста пусть будет так
@@ -28,6 +29,7 @@ func (n *Notifier) Run(ctx context.Context) {
 	log.Info().Msg("started notifier goroutine")
 	notifyTicker := time.NewTicker(10 * time.Second)
 	oldTicker := time.NewTicker(10 * time.Second)
+	wishlistTicker := time.NewTicker(10 * time.Second)
 
 	for {
 		select {
@@ -37,6 +39,8 @@ func (n *Notifier) Run(ctx context.Context) {
 			go n.sendNotifications(ctx)
 		case <-oldTicker.C:
 			go n.resetNotified(ctx)
+		case <-wishlistTicker.C:
+			go n.sendWishlistChanges(ctx)
 		}
 	}
 }
@@ -80,6 +84,47 @@ func (n *Notifier) sendNotifications(ctx context.Context) {
 	}
 }
 
+// sendWishlistChanges сообщает фронтам об изменённых вишлистах, не чаще чем раз в wishlistInterval на пользователя
+func (n *Notifier) sendWishlistChanges(ctx context.Context) {
+	changes, err := n.s.GetWishlistChanges(ctx, n.wishlistInterval)
+	if err != nil {
+		log.Err(err).Msg("error getting wishlist changes")
+		return
+	}
+
+	for _, change := range changes {
+		log.Info().Msgf("sending wishlist change for %s", change.FIO)
+
+		body, err := json.Marshal(change)
+		if err != nil {
+			log.Err(err).Msg("error marshaling wishlist change body")
+			continue
+		}
+
+		var resp *http.Response
+		switch change.Front {
+		case model.TelegramFront:
+			resp, err = n.c.Post(fmt.Sprintf("http://bot:8090/wishlist"), "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Err(err).Msg("error sending wishlist change")
+				continue
+			}
+		default:
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			log.Error().Int("code", resp.StatusCode).Msg("error sending wishlist change")
+			continue
+		}
+
+		if err = n.s.SetWishlistNotified(ctx, change.ID); err != nil {
+			log.Err(err).Msg("error setting wishlist notified, change will be repeated")
+		}
+	}
+}
+
 func (n *Notifier) resetNotified(ctx context.Context) {
 	if err := n.s.SetOldBirthdays(ctx, n.daysBeforeBirthday); err != nil {
 		log.Err(err).Msg("error resetting notified")
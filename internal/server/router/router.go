@@ -12,14 +12,22 @@ func New(s storage.Storage) chi.Router {
 	getUserHandler := handlers.NewGetUserHandler(s)
 	getUsersHandler := handlers.NewGetUsersHandler(s)
 	addUserHandler := handlers.NewAdduserHandler(s)
+	updateUserHandler := handlers.NewUpdateUserHandler(s)
 	subscribeHander := handlers.NewSubscribeHandler(s)
 	unsubscribeHandler := handlers.NewUnsubscribeHandler(s)
+	wishlistUpdatesHandler := handlers.NewWishlistUpdatesHandler(s)
+	getWishlistHandler := handlers.NewGetWishlistHandler(s)
+	addWishlistItemHandler := handlers.NewAddWishlistItemHandler(s)
+	deleteWishlistItemHandler := handlers.NewDeleteWishlistItemHandler(s)
+	claimHandler := handlers.NewClaimHandler(s)
+	unclaimHandler := handlers.NewUnclaimHandler(s)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/add", addUserHandler.ServeHTTP)
+		r.Post("/update", updateUserHandler.ServeHTTP)
 		r.Get("/get/{front}", getUsersHandler.ServeHTTP)
 		r.Get("/get/{front}/{userUID}", getUserHandler.ServeHTTP)
 	})
@@ -27,6 +35,15 @@ func New(s storage.Storage) chi.Router {
 	r.Route("/subscriptions", func(r chi.Router) {
 		r.Post("/subscribe", subscribeHander.ServeHTTP)
 		r.Post("/unsubscribe", unsubscribeHandler.ServeHTTP)
+		r.Post("/wishlist", wishlistUpdatesHandler.ServeHTTP)
+	})
+
+	r.Route("/wishlist", func(r chi.Router) {
+		r.Get("/get/{front}/{userUID}", getWishlistHandler.ServeHTTP)
+		r.Post("/add", addWishlistItemHandler.ServeHTTP)
+		r.Post("/delete", deleteWishlistItemHandler.ServeHTTP)
+		r.Post("/claim", claimHandler.ServeHTTP)
+		r.Post("/unclaim", unclaimHandler.ServeHTTP)
 	})
 
 	return r
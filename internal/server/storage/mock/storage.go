@@ -12,6 +12,7 @@ package mock_storage
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/smakimka/balb/internal/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,35 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddWishlistItem mocks base method.
+func (m *MockStorage) AddWishlistItem(ctx context.Context, item *model.WishlistItem) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWishlistItem", ctx, item)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWishlistItem indicates an expected call of AddWishlistItem.
+func (mr *MockStorageMockRecorder) AddWishlistItem(ctx, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWishlistItem", reflect.TypeOf((*MockStorage)(nil).AddWishlistItem), ctx, item)
+}
+
+// ClaimWishlistItem mocks base method.
+func (m *MockStorage) ClaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimWishlistItem indicates an expected call of ClaimWishlistItem.
+func (mr *MockStorageMockRecorder) ClaimWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWishlistItem", reflect.TypeOf((*MockStorage)(nil).ClaimWishlistItem), ctx, data)
+}
+
 // CreateUser mocks base method.
 func (m *MockStorage) CreateUser(ctx context.Context, u *model.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +85,20 @@ func (mr *MockStorageMockRecorder) CreateUser(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), ctx, u)
 }
 
+// DeleteWishlistItem mocks base method.
+func (m *MockStorage) DeleteWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWishlistItem indicates an expected call of DeleteWishlistItem.
+func (mr *MockStorageMockRecorder) DeleteWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWishlistItem", reflect.TypeOf((*MockStorage)(nil).DeleteWishlistItem), ctx, data)
+}
+
 // GetBirthdays mocks base method.
 func (m *MockStorage) GetBirthdays(ctx context.Context, daysLimit int) ([]model.NotifyRequest, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +144,36 @@ func (mr *MockStorageMockRecorder) GetUsers(ctx, front any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockStorage)(nil).GetUsers), ctx, front)
 }
 
+// GetWishlistChanges mocks base method.
+func (m *MockStorage) GetWishlistChanges(ctx context.Context, interval time.Duration) ([]model.WishlistChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlistChanges", ctx, interval)
+	ret0, _ := ret[0].([]model.WishlistChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlistChanges indicates an expected call of GetWishlistChanges.
+func (mr *MockStorageMockRecorder) GetWishlistChanges(ctx, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlistChanges", reflect.TypeOf((*MockStorage)(nil).GetWishlistChanges), ctx, interval)
+}
+
+// GetWishlistItems mocks base method.
+func (m *MockStorage) GetWishlistItems(ctx context.Context, front int, uid string) ([]model.WishlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlistItems", ctx, front, uid)
+	ret0, _ := ret[0].([]model.WishlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlistItems indicates an expected call of GetWishlistItems.
+func (mr *MockStorageMockRecorder) GetWishlistItems(ctx, front, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlistItems", reflect.TypeOf((*MockStorage)(nil).GetWishlistItems), ctx, front, uid)
+}
+
 // Init mocks base method.
 func (m *MockStorage) Init(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -142,6 +216,34 @@ func (mr *MockStorageMockRecorder) SetOldBirthdays(ctx, daysLimit any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOldBirthdays", reflect.TypeOf((*MockStorage)(nil).SetOldBirthdays), ctx, daysLimit)
 }
 
+// SetWishlistNotified mocks base method.
+func (m *MockStorage) SetWishlistNotified(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistNotified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistNotified indicates an expected call of SetWishlistNotified.
+func (mr *MockStorageMockRecorder) SetWishlistNotified(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistNotified", reflect.TypeOf((*MockStorage)(nil).SetWishlistNotified), ctx, userID)
+}
+
+// SetWishlistUpdates mocks base method.
+func (m *MockStorage) SetWishlistUpdates(ctx context.Context, data *model.WishlistUpdatesData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistUpdates", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistUpdates indicates an expected call of SetWishlistUpdates.
+func (mr *MockStorageMockRecorder) SetWishlistUpdates(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistUpdates", reflect.TypeOf((*MockStorage)(nil).SetWishlistUpdates), ctx, data)
+}
+
 // Subscribe mocks base method.
 func (m *MockStorage) Subscribe(ctx context.Context, data *model.SubscriptionData) error {
 	m.ctrl.T.Helper()
@@ -156,6 +258,20 @@ func (mr *MockStorageMockRecorder) Subscribe(ctx, data any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStorage)(nil).Subscribe), ctx, data)
 }
 
+// UnclaimWishlistItem mocks base method.
+func (m *MockStorage) UnclaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnclaimWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnclaimWishlistItem indicates an expected call of UnclaimWishlistItem.
+func (mr *MockStorageMockRecorder) UnclaimWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnclaimWishlistItem", reflect.TypeOf((*MockStorage)(nil).UnclaimWishlistItem), ctx, data)
+}
+
 // Unsubscribe mocks base method.
 func (m *MockStorage) Unsubscribe(ctx context.Context, data *model.SubscriptionData) error {
 	m.ctrl.T.Helper()
@@ -252,6 +368,21 @@ func (mr *MockGetterMockRecorder) GetUsers(ctx, front any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockGetter)(nil).GetUsers), ctx, front)
 }
 
+// GetWishlistChanges mocks base method.
+func (m *MockGetter) GetWishlistChanges(ctx context.Context, interval time.Duration) ([]model.WishlistChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlistChanges", ctx, interval)
+	ret0, _ := ret[0].([]model.WishlistChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlistChanges indicates an expected call of GetWishlistChanges.
+func (mr *MockGetterMockRecorder) GetWishlistChanges(ctx, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlistChanges", reflect.TypeOf((*MockGetter)(nil).GetWishlistChanges), ctx, interval)
+}
+
 // MockUpdater is a mock of Updater interface.
 type MockUpdater struct {
 	ctrl     *gomock.Controller
@@ -303,6 +434,20 @@ func (mr *MockUpdaterMockRecorder) SetOldBirthdays(ctx, daysLimit any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOldBirthdays", reflect.TypeOf((*MockUpdater)(nil).SetOldBirthdays), ctx, daysLimit)
 }
 
+// SetWishlistNotified mocks base method.
+func (m *MockUpdater) SetWishlistNotified(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistNotified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistNotified indicates an expected call of SetWishlistNotified.
+func (mr *MockUpdaterMockRecorder) SetWishlistNotified(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistNotified", reflect.TypeOf((*MockUpdater)(nil).SetWishlistNotified), ctx, userID)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUpdater) UpdateUser(ctx context.Context, u *model.User) error {
 	m.ctrl.T.Helper()
@@ -378,6 +523,20 @@ func (m *MockSubscriber) EXPECT() *MockSubscriberMockRecorder {
 	return m.recorder
 }
 
+// SetWishlistUpdates mocks base method.
+func (m *MockSubscriber) SetWishlistUpdates(ctx context.Context, data *model.WishlistUpdatesData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWishlistUpdates", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWishlistUpdates indicates an expected call of SetWishlistUpdates.
+func (mr *MockSubscriberMockRecorder) SetWishlistUpdates(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWishlistUpdates", reflect.TypeOf((*MockSubscriber)(nil).SetWishlistUpdates), ctx, data)
+}
+
 // Subscribe mocks base method.
 func (m *MockSubscriber) Subscribe(ctx context.Context, data *model.SubscriptionData) error {
 	m.ctrl.T.Helper()
@@ -405,3 +564,98 @@ func (mr *MockSubscriberMockRecorder) Unsubscribe(ctx, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockSubscriber)(nil).Unsubscribe), ctx, data)
 }
+
+// MockWishlister is a mock of Wishlister interface.
+type MockWishlister struct {
+	ctrl     *gomock.Controller
+	recorder *MockWishlisterMockRecorder
+}
+
+// MockWishlisterMockRecorder is the mock recorder for MockWishlister.
+type MockWishlisterMockRecorder struct {
+	mock *MockWishlister
+}
+
+// NewMockWishlister creates a new mock instance.
+func NewMockWishlister(ctrl *gomock.Controller) *MockWishlister {
+	mock := &MockWishlister{ctrl: ctrl}
+	mock.recorder = &MockWishlisterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWishlister) EXPECT() *MockWishlisterMockRecorder {
+	return m.recorder
+}
+
+// AddWishlistItem mocks base method.
+func (m *MockWishlister) AddWishlistItem(ctx context.Context, item *model.WishlistItem) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWishlistItem", ctx, item)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWishlistItem indicates an expected call of AddWishlistItem.
+func (mr *MockWishlisterMockRecorder) AddWishlistItem(ctx, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWishlistItem", reflect.TypeOf((*MockWishlister)(nil).AddWishlistItem), ctx, item)
+}
+
+// ClaimWishlistItem mocks base method.
+func (m *MockWishlister) ClaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimWishlistItem indicates an expected call of ClaimWishlistItem.
+func (mr *MockWishlisterMockRecorder) ClaimWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWishlistItem", reflect.TypeOf((*MockWishlister)(nil).ClaimWishlistItem), ctx, data)
+}
+
+// DeleteWishlistItem mocks base method.
+func (m *MockWishlister) DeleteWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWishlistItem indicates an expected call of DeleteWishlistItem.
+func (mr *MockWishlisterMockRecorder) DeleteWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWishlistItem", reflect.TypeOf((*MockWishlister)(nil).DeleteWishlistItem), ctx, data)
+}
+
+// GetWishlistItems mocks base method.
+func (m *MockWishlister) GetWishlistItems(ctx context.Context, front int, uid string) ([]model.WishlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlistItems", ctx, front, uid)
+	ret0, _ := ret[0].([]model.WishlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlistItems indicates an expected call of GetWishlistItems.
+func (mr *MockWishlisterMockRecorder) GetWishlistItems(ctx, front, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlistItems", reflect.TypeOf((*MockWishlister)(nil).GetWishlistItems), ctx, front, uid)
+}
+
+// UnclaimWishlistItem mocks base method.
+func (m *MockWishlister) UnclaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnclaimWishlistItem", ctx, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnclaimWishlistItem indicates an expected call of UnclaimWishlistItem.
+func (mr *MockWishlisterMockRecorder) UnclaimWishlistItem(ctx, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnclaimWishlistItem", reflect.TypeOf((*MockWishlister)(nil).UnclaimWishlistItem), ctx, data)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -55,6 +56,33 @@ func (s *PGStorage) Init(ctx context.Context) error {
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `alter table users
+        add column if not exists wishlist_changed_at timestamp,
+        add column if not exists wishlist_notified_at timestamp`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `alter table subscriptions
+        add column if not exists wishlist_updates bool default true`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `create table if not exists wishlist_items (
+        id serial primary key,
+        user_id int references users(id),
+        title text,
+        url text default '',
+        price_from int default 0,
+        price_to int default 0,
+        priority int default 2,
+        claimed_by int references users(id)
+    )`)
+	if err != nil {
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -125,8 +153,9 @@ func (s *PGStorage) UpdateUser(ctx context.Context, u *model.User) error {
 	defer tx.Rollback(ctx)
 
 	cmd, err := tx.Exec(ctx, `update users set 
-    fio = $1, birthday = $2, wishlist = $3 where
-    front = $4 and uid like $5)`, u.FIO, u.Birthday, u.Wishlist, u.Front, u.UID)
+    fio = $1, birthday = $2, wishlist = $3,
+    wishlist_changed_at = case when wishlist is distinct from $3 then now() else wishlist_changed_at end
+    where front = $4 and uid like $5`, u.FIO, u.Birthday, u.Wishlist, u.Front, u.UID)
 	if err != nil {
 		return err
 	}
@@ -237,10 +266,98 @@ func (s *PGStorage) Unsubscribe(ctx context.Context, data *model.SubscriptionDat
 	return nil
 }
 
+func (s *PGStorage) SetWishlistUpdates(ctx context.Context, data *model.WishlistUpdatesData) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update subscriptions as s set wishlist_updates = $4
+    from users as sub, users as u
+    where s.user_id = u.id
+    and s.subscriber_id = sub.id
+    and sub.front = $1 
+    and u.front = $1
+    and sub.uid like $2 
+    and u.uid like $3`, data.Front, data.SubscriberUID, data.UserUID, data.Enabled)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetWishlistChanges возвращает пользователей, изменивших вишлист после последнего уведомления,
+// но не чаще чем раз в interval
+func (s *PGStorage) GetWishlistChanges(ctx context.Context, interval time.Duration) ([]model.WishlistChange, error) {
+	res := []model.WishlistChange{}
+
+	rows, err := s.p.Query(ctx, `SELECT u.id, u.front, u.uid, u.fio, u.wishlist,
+    array_remove(array_agg(sub.uid), null) as subscriber_uids
+    FROM users as u
+    left join subscriptions as s on u.id = s.user_id and s.wishlist_updates
+    left join users as sub on s.subscriber_id = sub.id
+    where u.wishlist_changed_at is not null
+    and (u.wishlist_notified_at is null or u.wishlist_changed_at > u.wishlist_notified_at)
+    and (u.wishlist_notified_at is null or u.wishlist_notified_at < now() - $1::interval)
+    group by u.id`, interval)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change := model.WishlistChange{}
+
+		err = rows.Scan(&change.ID, &change.Front, &change.UID, &change.FIO, &change.Wishlist, &change.Users)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (s *PGStorage) SetWishlistNotified(ctx context.Context, userID int) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update users set wishlist_notified_at = now() where id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *PGStorage) GetBirthdays(ctx context.Context, daysLimit int) ([]model.NotifyRequest, error) {
 	res := []model.NotifyRequest{}
 
-	rows, err := s.p.Query(ctx, fmt.Sprintf(`SELECT u.id, u.front, u.fio, u.birthday, u.wishlist, array_agg(sub.uid) as subscriber_uids
+	rows, err := s.p.Query(ctx, fmt.Sprintf(`SELECT u.id, u.front, u.uid, u.fio, u.birthday, u.wishlist, array_agg(sub.uid) as subscriber_uids
     FROM users as u
     join subscriptions as s on u.id = s.user_id
     join users as sub on s.subscriber_id = sub.id
@@ -253,7 +370,7 @@ func (s *PGStorage) GetBirthdays(ctx context.Context, daysLimit int) ([]model.No
 	for rows.Next() {
 		req := model.NotifyRequest{}
 
-		err = rows.Scan(&req.ID, &req.Front, &req.FIO, &req.Birthday, &req.Wishlist, &req.Users)
+		err = rows.Scan(&req.ID, &req.Front, &req.UID, &req.FIO, &req.Birthday, &req.Wishlist, &req.Users)
 		if err != nil {
 			return nil, err
 		}
@@ -310,3 +427,163 @@ func (s *PGStorage) SetNotified(ctx context.Context, userID int, notified bool)
 
 	return nil
 }
+
+func (s *PGStorage) GetWishlistItems(ctx context.Context, front int, uid string) ([]model.WishlistItem, error) {
+	items := []model.WishlistItem{}
+
+	rows, err := s.p.Query(ctx, `select i.id, i.title, i.url, i.price_from, i.price_to, i.priority, coalesce(c.uid, '')
+    from wishlist_items as i
+    join users as u on u.id = i.user_id
+    left join users as c on c.id = i.claimed_by
+    where u.front = $1 and u.uid like $2
+    order by i.priority, i.id`, front, uid)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := model.WishlistItem{Front: front, UserUID: uid}
+
+		err = rows.Scan(&item.ID, &item.Title, &item.URL, &item.PriceFrom, &item.PriceTo, &item.Priority, &item.ClaimedBy)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return items, err
+	}
+
+	return items, nil
+}
+
+func (s *PGStorage) AddWishlistItem(ctx context.Context, item *model.WishlistItem) (int, error) {
+	var newItemID int
+
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return newItemID, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := s.txGetUser(ctx, tx, item.Front, item.UserUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newItemID, ErrUserNotFound
+		}
+		return newItemID, err
+	}
+
+	row := tx.QueryRow(ctx, `insert into wishlist_items as i (user_id, title, url, price_from, price_to, priority) 
+    values ($1, $2, $3, $4, $5, $6) returning i.id`, user.ID, item.Title, item.URL, item.PriceFrom, item.PriceTo, item.Priority)
+	if err = row.Scan(&newItemID); err != nil {
+		return newItemID, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return newItemID, err
+	}
+
+	return newItemID, nil
+}
+
+func (s *PGStorage) DeleteWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `delete from wishlist_items as i 
+    using users as u
+    where i.user_id = u.id
+    and i.id = $1
+    and u.front = $2
+    and u.uid like $3`, data.ItemID, data.Front, data.UID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) ClaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	claimer, err := s.txGetUser(ctx, tx, data.Front, data.UID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	var ownerID int
+	var claimedBy *int
+	row := tx.QueryRow(ctx, `select user_id, claimed_by from wishlist_items where id = $1 for update`, data.ItemID)
+	if err = row.Scan(&ownerID, &claimedBy); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrItemNotFound
+		}
+		return err
+	}
+
+	if ownerID == claimer.ID {
+		return ErrOwnItem
+	}
+	if claimedBy != nil {
+		return ErrItemAlreadyClaimed
+	}
+
+	_, err = tx.Exec(ctx, `update wishlist_items set claimed_by = $1 where id = $2`, claimer.ID, data.ItemID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PGStorage) UnclaimWishlistItem(ctx context.Context, data *model.ItemAction) error {
+	tx, err := s.p.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, `update wishlist_items as i set claimed_by = null
+    from users as c
+    where i.claimed_by = c.id
+    and i.id = $1
+    and c.front = $2
+    and c.uid like $3`, data.ItemID, data.Front, data.UID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrItemNotFound
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
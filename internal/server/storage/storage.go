@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/smakimka/balb/internal/model"
 )
@@ -11,6 +12,9 @@ var ErrUserAlreadyExists = errors.New("user already exists")
 var ErrUserNotFound = errors.New("user not found")
 var ErrSubscriptionAlreadyExists = errors.New("subscription already exists")
 var ErrSubscriptionNotFound = errors.New("subscription not found")
+var ErrItemNotFound = errors.New("item not found")
+var ErrItemAlreadyClaimed = errors.New("item already claimed")
+var ErrOwnItem = errors.New("own item")
 
 type Storage interface {
 	Init(ctx context.Context) error
@@ -18,18 +22,21 @@ type Storage interface {
 	Updater
 	Creater
 	Subscriber
+	Wishlister
 }
 
 type Getter interface {
 	GetBirthdays(ctx context.Context, daysLimit int) ([]model.NotifyRequest, error)
 	GetUser(ctx context.Context, front int, uid string) (*model.User, error)
 	GetUsers(ctx context.Context, front int) ([]model.User, error)
+	GetWishlistChanges(ctx context.Context, interval time.Duration) ([]model.WishlistChange, error)
 }
 
 type Updater interface {
 	SetOldBirthdays(ctx context.Context, daysLimit int) error
 	SetNotified(ctx context.Context, userID int, notified bool) error
 	UpdateUser(ctx context.Context, u *model.User) error
+	SetWishlistNotified(ctx context.Context, userID int) error
 }
 
 type Creater interface {
@@ -39,4 +46,13 @@ type Creater interface {
 type Subscriber interface {
 	Subscribe(ctx context.Context, data *model.SubscriptionData) error
 	Unsubscribe(ctx context.Context, data *model.SubscriptionData) error
+	SetWishlistUpdates(ctx context.Context, data *model.WishlistUpdatesData) error
+}
+
+type Wishlister interface {
+	GetWishlistItems(ctx context.Context, front int, uid string) ([]model.WishlistItem, error)
+	AddWishlistItem(ctx context.Context, item *model.WishlistItem) (int, error)
+	DeleteWishlistItem(ctx context.Context, data *model.ItemAction) error
+	ClaimWishlistItem(ctx context.Context, data *model.ItemAction) error
+	UnclaimWishlistItem(ctx context.Context, data *model.ItemAction) error
 }
@@ -0,0 +1,306 @@
+package tgemu
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// maxUpdatesWait дольше этого getUpdates не ждёт, даже если бот просит
+const maxUpdatesWait = 50 * time.Second
+
+// okMethods методы, на которые достаточно ответить true: бот их только вызывает, а смотреть на них можно в /control/calls
+var okMethods = map[string]bool{
+	"answerCallbackQuery":    true,
+	"pinChatMessage":         true,
+	"unpinChatMessage":       true,
+	"unpinAllChatMessages":   true,
+	"deleteMessage":          true,
+	"setChatTitle":           true,
+	"setChatDescription":     true,
+	"setChatPhoto":           true,
+	"revokeChatInviteLink":   true,
+	"approveChatJoinRequest": true,
+	"declineChatJoinRequest": true,
+	"unbanChatMember":        true,
+	"banChatMember":          true,
+	"leaveChat":              true,
+	"closeForumTopic":        true,
+	"sendChatAction":         true,
+	"setMyCommands":          true,
+}
+
+type apiResponse struct {
+	Ok          bool   `json:"ok"`
+	Result      any    `json:"result,omitempty"`
+	ErrorCode   int    `json:"error_code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(apiResponse{Ok: true, Result: result})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(apiResponse{ErrorCode: code, Description: description})
+}
+
+func (e *Emulator) serveBotAPI(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "token") != e.token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// tgbotapi шлёт обычные формы, а файлы - multipart
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(32 << 20)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: can't parse parameters")
+		return
+	}
+
+	params := map[string]string{}
+	for key := range r.Form {
+		params[key] = r.Form.Get(key)
+	}
+
+	method := chi.URLParam(r, "method")
+	if method != "getUpdates" {
+		e.m.Lock()
+		e.calls = append(e.calls, Call{Method: method, Params: params})
+		e.m.Unlock()
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, e.bot)
+	case "getUpdates":
+		e.getUpdates(w, r, params)
+	case "sendMessage":
+		e.sendMessage(w, params)
+	case "sendPoll":
+		e.sendPoll(w, params)
+	case "editMessageText", "editMessageReplyMarkup":
+		e.editMessage(w, method, params)
+	case "stopPoll":
+		writeResult(w, tgbotapi.Poll{ID: params["message_id"], IsClosed: true})
+	case "exportChatInviteLink":
+		writeResult(w, e.newLink())
+	case "createChatInviteLink":
+		writeResult(w, tgbotapi.ChatInviteLink{
+			InviteLink:         e.newLink(),
+			Creator:            e.bot,
+			CreatesJoinRequest: params["creates_join_request"] == "true",
+			Name:               params["name"],
+		})
+	case "getChat":
+		e.getChat(w, params)
+	case "getChatMember":
+		e.getChatMember(w, params)
+	case "createForumTopic":
+		e.m.Lock()
+		e.threadID++
+		threadID := e.threadID
+		e.m.Unlock()
+		writeResult(w, map[string]any{"message_thread_id": threadID, "name": params["name"]})
+	default:
+		if !okMethods[method] {
+			log.Warn().Str("method", method).Msg("unsupported bot api method")
+			writeError(w, http.StatusNotFound, "Not Found: method not supported by emulator")
+			return
+		}
+		writeResult(w, true)
+	}
+}
+
+// getUpdates отдаёт апдейты начиная с offset, если их нет - ждёт timeout секунд, как long polling у Telegram
+func (e *Emulator) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	offset, _ := strconv.Atoi(params["offset"])
+	timeout, _ := strconv.Atoi(params["timeout"])
+	deadline := time.NewTimer(min(time.Duration(timeout)*time.Second, maxUpdatesWait))
+	defer deadline.Stop()
+
+	for {
+		e.m.Lock()
+		// Апдейты до offset бот подтвердил, больше их хранить незачем
+		i := 0
+		for i < len(e.updates) && e.updates[i].UpdateID < offset {
+			i++
+		}
+		e.updates = e.updates[i:]
+		updates := append([]tgbotapi.Update{}, e.updates...)
+		wake := e.wake
+		e.m.Unlock()
+
+		if len(updates) > 0 {
+			writeResult(w, updates)
+			return
+		}
+
+		select {
+		case <-wake:
+		case <-deadline.C:
+			writeResult(w, updates)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (e *Emulator) sendMessage(w http.ResponseWriter, params map[string]string) {
+	chatID, err := strconv.ParseInt(params["chat_id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat_id is not a number")
+		return
+	}
+	threadID, _ := strconv.Atoi(params["message_thread_id"])
+
+	e.m.Lock()
+	message := e.newMessage(chatID, &e.bot, params["text"])
+	e.sent = append(e.sent, Sent{
+		Method:      "sendMessage",
+		ChatID:      chatID,
+		MessageID:   message.MessageID,
+		ThreadID:    threadID,
+		Text:        params["text"],
+		ReplyMarkup: rawMarkup(params["reply_markup"]),
+	})
+	e.m.Unlock()
+
+	writeResult(w, message)
+}
+
+func (e *Emulator) sendPoll(w http.ResponseWriter, params map[string]string) {
+	chatID, err := strconv.ParseInt(params["chat_id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat_id is not a number")
+		return
+	}
+
+	options := []string{}
+	json.Unmarshal([]byte(params["options"]), &options)
+
+	e.m.Lock()
+	message := e.newMessage(chatID, &e.bot, "")
+	message.Poll = &tgbotapi.Poll{ID: fmt.Sprint(message.MessageID), Question: params["question"]}
+	for _, option := range options {
+		message.Poll.Options = append(message.Poll.Options, tgbotapi.PollOption{Text: option})
+	}
+	e.sent = append(e.sent, Sent{
+		Method:    "sendPoll",
+		ChatID:    chatID,
+		MessageID: message.MessageID,
+		Text:      params["question"] + "\n" + strings.Join(options, "\n"),
+	})
+	e.m.Unlock()
+
+	writeResult(w, message)
+}
+
+func (e *Emulator) editMessage(w http.ResponseWriter, method string, params map[string]string) {
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+	messageID, _ := strconv.Atoi(params["message_id"])
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	message, ok := e.messages[chatID][messageID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found")
+		return
+	}
+	if method == "editMessageText" {
+		message.Text = params["text"]
+		e.messages[chatID][messageID] = message
+	}
+
+	e.sent = append(e.sent, Sent{
+		Method:      method,
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        message.Text,
+		ReplyMarkup: rawMarkup(params["reply_markup"]),
+	})
+
+	writeResult(w, message)
+}
+
+func (e *Emulator) getChat(w http.ResponseWriter, params map[string]string) {
+	chatID, err := strconv.ParseInt(params["chat_id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat_id is not a number")
+		return
+	}
+
+	e.m.Lock()
+	chat := e.chat(chatID)
+	e.m.Unlock()
+
+	writeResult(w, chat)
+}
+
+func (e *Emulator) getChatMember(w http.ResponseWriter, params map[string]string) {
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+	userID, _ := strconv.ParseInt(params["user_id"], 10, 64)
+
+	e.m.Lock()
+	status, ok := e.members[[2]int64{chatID, userID}]
+	e.m.Unlock()
+
+	if !ok {
+		status = "member"
+		if userID == e.bot.ID {
+			status = "administrator"
+		}
+	}
+
+	writeResult(w, e.chatMember(&tgbotapi.User{ID: userID}, status))
+}
+
+// chatMember участник с указанным статусом, админам выдаются все права, которые нужны боту
+func (e *Emulator) chatMember(user *tgbotapi.User, status string) tgbotapi.ChatMember {
+	member := tgbotapi.ChatMember{User: user, Status: status}
+	if user.ID == e.bot.ID {
+		member.User = &e.bot
+	}
+	if member.Status == "administrator" {
+		member.CanInviteUsers = true
+		member.CanChangeInfo = true
+		member.CanPinMessages = true
+		member.CanRestrictMembers = true
+	}
+
+	return member
+}
+
+func (e *Emulator) newLink() string {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.linkID++
+	return fmt.Sprintf("https://t.me/+emu%d", e.linkID)
+}
+
+func rawMarkup(markup string) json.RawMessage {
+	if markup == "" {
+		return nil
+	}
+
+	return json.RawMessage(markup)
+}
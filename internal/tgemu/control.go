@@ -0,0 +1,215 @@
+package tgemu
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-chi/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/smakimka/balb/internal/model"
+)
+
+// MessageRequest сообщение пользователя боту, если ChatID не задан - пишет в личку
+type MessageRequest struct {
+	ChatID    int64  `json:"chat_id"`
+	FromID    int64  `json:"from_id"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+	Text      string `json:"text"`
+	// ChatType и ChatTitle запоминаются для чата при первом сообщении в него
+	ChatType  string `json:"chat_type"`
+	ChatTitle string `json:"chat_title"`
+}
+
+func (m *MessageRequest) Bind(r *http.Request) error {
+	if m.FromID == 0 {
+		return errors.New("from_id is required")
+	}
+	if m.ChatID == 0 {
+		m.ChatID = m.FromID
+	}
+	if m.FirstName == "" {
+		m.FirstName = fmt.Sprintf("user%d", m.FromID)
+	}
+
+	return nil
+}
+
+// CallbackRequest нажатие пользователем кнопки под сообщением бота
+type CallbackRequest struct {
+	ChatID    int64  `json:"chat_id"`
+	FromID    int64  `json:"from_id"`
+	MessageID int    `json:"message_id"`
+	Data      string `json:"data"`
+}
+
+func (c *CallbackRequest) Bind(r *http.Request) error {
+	if c.FromID == 0 || c.MessageID == 0 {
+		return errors.New("from_id and message_id are required")
+	}
+	if c.ChatID == 0 {
+		c.ChatID = c.FromID
+	}
+
+	return nil
+}
+
+// MemberRequest смена статуса участника беседы: member, left, kicked, administrator...
+type MemberRequest struct {
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id"`
+	Status string `json:"status"`
+}
+
+func (m *MemberRequest) Bind(r *http.Request) error {
+	if m.ChatID == 0 || m.UserID == 0 || m.Status == "" {
+		return errors.New("chat_id, user_id and status are required")
+	}
+
+	return nil
+}
+
+func (e *Emulator) postMessage(w http.ResponseWriter, r *http.Request) {
+	data := &MessageRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: err.Error()})
+		return
+	}
+
+	e.m.Lock()
+	if _, ok := e.chats[data.ChatID]; !ok && data.ChatType != "" {
+		e.chats[data.ChatID] = tgbotapi.Chat{ID: data.ChatID, Type: data.ChatType, Title: data.ChatTitle}
+	}
+
+	from := &tgbotapi.User{ID: data.FromID, FirstName: data.FirstName, UserName: data.Username}
+	message := e.newMessage(data.ChatID, from, data.Text)
+	if command, _, _ := strings.Cut(data.Text, " "); strings.HasPrefix(command, "/") {
+		// Длина сущности в Telegram считается в UTF-16
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(utf16.Encode([]rune(command)))}}
+	}
+	update := e.addUpdate(tgbotapi.Update{Message: &message})
+	e.m.Unlock()
+
+	render.JSON(w, r, update)
+}
+
+func (e *Emulator) postCallback(w http.ResponseWriter, r *http.Request) {
+	data := &CallbackRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: err.Error()})
+		return
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	message, ok := e.messages[data.ChatID][data.MessageID]
+	if !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, model.Response{Msg: "message not found"})
+		return
+	}
+
+	// id запроса совпадает с id апдейта, в котором он придёт
+	update := e.addUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      fmt.Sprint(e.updateID + 1),
+		From:    &tgbotapi.User{ID: data.FromID, FirstName: fmt.Sprintf("user%d", data.FromID)},
+		Message: &message,
+		Data:    data.Data,
+	}})
+
+	render.JSON(w, r, update)
+}
+
+func (e *Emulator) postMember(w http.ResponseWriter, r *http.Request) {
+	data := &MemberRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, model.Response{Msg: err.Error()})
+		return
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	isBot := data.UserID == e.bot.ID
+
+	key := [2]int64{data.ChatID, data.UserID}
+	oldStatus, ok := e.members[key]
+	if !ok {
+		oldStatus = "left"
+		if isBot {
+			oldStatus = "administrator"
+		}
+	}
+	e.members[key] = data.Status
+
+	user := &tgbotapi.User{ID: data.UserID, FirstName: fmt.Sprintf("user%d", data.UserID)}
+	if isBot {
+		user = &e.bot
+	}
+	changed := &tgbotapi.ChatMemberUpdated{
+		Chat:          e.chat(data.ChatID),
+		From:          *user,
+		Date:          int(time.Now().Unix()),
+		OldChatMember: e.chatMember(user, oldStatus),
+		NewChatMember: e.chatMember(user, data.Status),
+	}
+
+	// Про изменения самого бота Telegram присылает my_chat_member, а не chat_member
+	update := tgbotapi.Update{ChatMember: changed}
+	if isBot {
+		update = tgbotapi.Update{MyChatMember: changed}
+	}
+	update = e.addUpdate(update)
+
+	render.JSON(w, r, update)
+}
+
+// getSent отправленные ботом сообщения, ?chat_id= оставляет только сообщения в этот чат
+func (e *Emulator) getSent(w http.ResponseWriter, r *http.Request) {
+	var chatID int64
+	if param := r.URL.Query().Get("chat_id"); param != "" {
+		var err error
+		if chatID, err = strconv.ParseInt(param, 10, 64); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, model.Response{Msg: "wrong chat_id"})
+			return
+		}
+	}
+
+	e.m.Lock()
+	res := []Sent{}
+	for _, sent := range e.sent {
+		if chatID == 0 || sent.ChatID == chatID {
+			res = append(res, sent)
+		}
+	}
+	e.m.Unlock()
+
+	render.JSON(w, r, res)
+}
+
+// getCalls все вызовы Bot API кроме getUpdates, ?method= оставляет только вызовы этого метода
+func (e *Emulator) getCalls(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Query().Get("method")
+
+	e.m.Lock()
+	res := []Call{}
+	for _, call := range e.calls {
+		if method == "" || call.Method == method {
+			res = append(res, call)
+		}
+	}
+	e.m.Unlock()
+
+	render.JSON(w, r, res)
+}
@@ -0,0 +1,124 @@
+// Package tgemu эмулятор той части Telegram Bot API, которой пользуется бот, чтобы гонять его локально без BotFather.
+// Бот ходит в эмулятор через tgbotapi.NewBotAPIWithAPIEndpoint, а сообщения пользователей
+// подкладываются и отправленные ботом смотрятся через управляющий HTTP API
+package tgemu
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Call вызов метода Bot API ботом
+type Call struct {
+	Method string            `json:"method"`
+	Params map[string]string `json:"params"`
+}
+
+// Sent сообщение, отправленное или отредактированное ботом
+type Sent struct {
+	Method      string          `json:"method"`
+	ChatID      int64           `json:"chat_id"`
+	MessageID   int             `json:"message_id"`
+	ThreadID    int             `json:"message_thread_id,omitempty"`
+	Text        string          `json:"text"`
+	ReplyMarkup json.RawMessage `json:"reply_markup,omitempty"`
+}
+
+// Emulator хранит апдейты, чаты и всё, что отправил бот, в памяти, между запусками ничего не сохраняется
+type Emulator struct {
+	token string
+	bot   tgbotapi.User
+
+	m       sync.Mutex
+	updates []tgbotapi.Update
+	// wake закрывается и пересоздаётся при каждом новом апдейте, чтобы разбудить ждущий getUpdates
+	wake      chan struct{}
+	updateID  int
+	messageID int
+	linkID    int
+	threadID  int
+
+	chats map[int64]tgbotapi.Chat
+	// members статусы участников по {chat id, user id}, бот по умолчанию админ во всех беседах
+	members  map[[2]int64]string
+	messages map[int64]map[int]tgbotapi.Message
+	sent     []Sent
+	calls    []Call
+}
+
+func New(token string, username string) *Emulator {
+	return &Emulator{
+		token:    token,
+		bot:      tgbotapi.User{ID: 1, IsBot: true, FirstName: username, UserName: username},
+		wake:     make(chan struct{}),
+		chats:    map[int64]tgbotapi.Chat{},
+		members:  map[[2]int64]string{},
+		messages: map[int64]map[int]tgbotapi.Message{},
+	}
+}
+
+// Handler Bot API на /bot<token>/<метод> и управляющий API на /control
+func (e *Emulator) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+
+	r.HandleFunc("/bot{token}/{method}", e.serveBotAPI)
+
+	r.Route("/control", func(r chi.Router) {
+		r.Post("/messages", e.postMessage)
+		r.Post("/callbacks", e.postCallback)
+		r.Post("/members", e.postMember)
+		r.Get("/sent", e.getSent)
+		r.Get("/calls", e.getCalls)
+	})
+
+	return r
+}
+
+// addUpdate кладёт апдейт в очередь и будит getUpdates, вызывать под e.m
+func (e *Emulator) addUpdate(update tgbotapi.Update) tgbotapi.Update {
+	e.updateID++
+	update.UpdateID = e.updateID
+	e.updates = append(e.updates, update)
+
+	close(e.wake)
+	e.wake = make(chan struct{})
+
+	return update
+}
+
+// chat известный эмулятору чат, новые создаются при первом сообщении в них, вызывать под e.m
+func (e *Emulator) chat(chatID int64) tgbotapi.Chat {
+	chat, ok := e.chats[chatID]
+	if !ok {
+		chat = tgbotapi.Chat{ID: chatID, Type: "private"}
+	}
+
+	return chat
+}
+
+// newMessage сообщение от бота или пользователя, вызывать под e.m
+func (e *Emulator) newMessage(chatID int64, from *tgbotapi.User, text string) tgbotapi.Message {
+	e.messageID++
+	chat := e.chat(chatID)
+	message := tgbotapi.Message{
+		MessageID: e.messageID,
+		From:      from,
+		Chat:      &chat,
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+
+	if e.messages[chatID] == nil {
+		e.messages[chatID] = map[int]tgbotapi.Message{}
+	}
+	e.messages[chatID][message.MessageID] = message
+
+	return message
+}
@@ -0,0 +1,186 @@
+package tgemu_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smakimka/balb/internal/tgemu"
+)
+
+func newEmulator(t *testing.T) (*tgbotapi.BotAPI, string) {
+	server := httptest.NewServer(tgemu.New("token", "emu_bot").Handler())
+	t.Cleanup(server.Close)
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", server.URL+"/bot%s/%s")
+	require.NoError(t, err)
+
+	return api, server.URL
+}
+
+func post(t *testing.T, url string, body any, res any) int {
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if res != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+	}
+
+	return resp.StatusCode
+}
+
+func get(t *testing.T, url string, res any) {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+}
+
+func TestGetMe(t *testing.T) {
+	api, url := newEmulator(t)
+	assert.Equal(t, "emu_bot", api.Self.UserName)
+
+	_, err := tgbotapi.NewBotAPIWithAPIEndpoint("wrong", url+"/bot%s/%s")
+	assert.Error(t, err)
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	api, url := newEmulator(t)
+
+	status := post(t, url+"/control/messages", tgemu.MessageRequest{FromID: 10, Text: "/start abc"}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	updates, err := api.GetUpdates(tgbotapi.NewUpdate(0))
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.NotNil(t, updates[0].Message)
+	assert.Equal(t, int64(10), updates[0].Message.Chat.ID)
+	assert.True(t, updates[0].Message.IsCommand())
+	assert.Equal(t, "start", updates[0].Message.Command())
+	assert.Equal(t, "abc", updates[0].Message.CommandArguments())
+
+	// Подтверждённые апдейты больше не отдаются
+	updates, err = api.GetUpdates(tgbotapi.NewUpdate(updates[0].UpdateID + 1))
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+
+	msg := tgbotapi.NewMessage(10, "Привет")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Да", "reg:confirm")))
+	sent, err := api.Send(msg)
+	require.NoError(t, err)
+
+	var res []tgemu.Sent
+	get(t, url+"/control/sent?chat_id=10", &res)
+	require.Len(t, res, 1)
+	assert.Equal(t, "Привет", res[0].Text)
+	assert.Equal(t, sent.MessageID, res[0].MessageID)
+	assert.Contains(t, string(res[0].ReplyMarkup), "reg:confirm")
+
+	status = post(t, url+"/control/callbacks", tgemu.CallbackRequest{FromID: 10, MessageID: sent.MessageID, Data: "reg:confirm"}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	updates, err = api.GetUpdates(tgbotapi.NewUpdate(0))
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.NotNil(t, updates[0].CallbackQuery)
+	assert.Equal(t, "reg:confirm", updates[0].CallbackQuery.Data)
+	assert.Equal(t, sent.MessageID, updates[0].CallbackQuery.Message.MessageID)
+}
+
+func TestCallbackToUnknownMessage(t *testing.T) {
+	_, url := newEmulator(t)
+
+	status := post(t, url+"/control/callbacks", tgemu.CallbackRequest{FromID: 10, MessageID: 42, Data: "x"}, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestGetUpdatesWaitsForMessage(t *testing.T) {
+	api, url := newEmulator(t)
+
+	// require нельзя вызывать не из горутины теста, поэтому ошибку отдаём через канал
+	errs := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		resp, err := http.Post(url+"/control/messages", "application/json", strings.NewReader(`{"from_id": 10, "text": "hi"}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+		errs <- err
+	}()
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 5
+	start := time.Now()
+	updates, err := api.GetUpdates(u)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.NoError(t, <-errs)
+}
+
+func TestChatMembers(t *testing.T) {
+	api, url := newEmulator(t)
+
+	me, err := api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: -100, UserID: api.Self.ID},
+	})
+	require.NoError(t, err)
+	assert.True(t, me.IsAdministrator())
+	assert.True(t, me.CanInviteUsers)
+
+	status := post(t, url+"/control/members", tgemu.MemberRequest{ChatID: -100, UserID: 10, Status: "member"}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	updates, err := api.GetUpdates(tgbotapi.NewUpdate(0))
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.NotNil(t, updates[0].ChatMember)
+	assert.Equal(t, "left", updates[0].ChatMember.OldChatMember.Status)
+	assert.Equal(t, "member", updates[0].ChatMember.NewChatMember.Status)
+
+	link, err := api.GetInviteLink(tgbotapi.ChatInviteLinkConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: -100}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, link)
+
+	var calls []tgemu.Call
+	get(t, url+"/control/calls?method=exportChatInviteLink", &calls)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "-100", calls[0].Params["chat_id"])
+}
+
+func TestBotMemberUpdate(t *testing.T) {
+	api, url := newEmulator(t)
+
+	status := post(t, url+"/control/members", tgemu.MemberRequest{ChatID: -100, UserID: api.Self.ID, Status: "member"}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	updates, err := api.GetUpdates(tgbotapi.NewUpdate(0))
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	assert.Nil(t, updates[0].ChatMember)
+	require.NotNil(t, updates[0].MyChatMember)
+	assert.Equal(t, api.Self.ID, updates[0].MyChatMember.NewChatMember.User.ID)
+	assert.True(t, updates[0].MyChatMember.OldChatMember.IsAdministrator())
+	assert.True(t, updates[0].MyChatMember.OldChatMember.CanInviteUsers)
+	assert.Equal(t, "member", updates[0].MyChatMember.NewChatMember.Status)
+
+	me, err := api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: -100, UserID: api.Self.ID},
+	})
+	require.NoError(t, err)
+	assert.False(t, me.IsAdministrator())
+}